@@ -0,0 +1,184 @@
+package gnomock
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// filesArchive creates a tar archive that includes the provided files. Keys of
+// both maps are absolute paths inside the container, so the archive should be
+// extracted into the root directory. Values of files are file contents, and
+// values of hostFiles are paths to files or directories on the host.
+func filesArchive(files map[string][]byte, hostFiles map[string]string) (io.Reader, error) {
+	buf := &bytes.Buffer{}
+	tw := tar.NewWriter(buf)
+
+	for _, dst := range sortedKeys(files) {
+		if err := writeTarFile(tw, dst, files[dst], 0o644); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, dst := range sortedKeys(hostFiles) {
+		if err := writeTarPath(tw, dst, hostFiles[dst]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("can't create archive: %w", err)
+	}
+
+	return buf, nil
+}
+
+func writeTarFile(tw *tar.Writer, dst string, content []byte, mode int64) error {
+	hdr := &tar.Header{
+		Name:     archivePath(dst),
+		Mode:     mode,
+		Size:     int64(len(content)),
+		Typeflag: tar.TypeReg,
+	}
+
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("can't add %s to archive: %w", dst, err)
+	}
+
+	if _, err := tw.Write(content); err != nil {
+		return fmt.Errorf("can't add %s to archive: %w", dst, err)
+	}
+
+	return nil
+}
+
+// writeTarPath adds a file or a directory located at src on the host into the
+// archive under dst path.
+func writeTarPath(tw *tar.Writer, dst, src string) error {
+	return filepath.WalkDir(src, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("can't read %s: %w", p, err)
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return fmt.Errorf("can't read %s: %w", p, err)
+		}
+
+		name := path.Join(dst, filepath.ToSlash(rel))
+
+		info, err := entry.Info()
+		if err != nil {
+			return fmt.Errorf("can't read %s: %w", p, err)
+		}
+
+		if info.IsDir() {
+			hdr := &tar.Header{
+				Name:     archivePath(name) + "/",
+				Mode:     int64(info.Mode().Perm()),
+				Typeflag: tar.TypeDir,
+			}
+
+			if err := tw.WriteHeader(hdr); err != nil {
+				return fmt.Errorf("can't add %s to archive: %w", p, err)
+			}
+
+			return nil
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		content, err := os.ReadFile(p) // nolint:gosec
+		if err != nil {
+			return fmt.Errorf("can't read %s: %w", p, err)
+		}
+
+		return writeTarFile(tw, name, content, int64(info.Mode().Perm()))
+	})
+}
+
+// extractArchive extracts a tar archive received from docker into dst path on
+// the host. The top level entry of the archive is the base name of the copied
+// file or directory, and it is replaced by dst.
+func extractArchive(r io.Reader, dst string) error {
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("can't read archive: %w", err)
+		}
+
+		_, rel, _ := strings.Cut(strings.TrimSuffix(hdr.Name, "/"), "/")
+		if rel != "" && !filepath.IsLocal(filepath.FromSlash(rel)) {
+			return fmt.Errorf("invalid path in archive: %s", hdr.Name)
+		}
+
+		target := filepath.Join(dst, filepath.FromSlash(rel))
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil { // nolint:gosec
+				return fmt.Errorf("can't create directory %s: %w", target, err)
+			}
+		case tar.TypeReg:
+			if err := extractFile(tr, target, hdr.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func extractFile(r io.Reader, target string, mode os.FileMode) (err error) {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil { // nolint:gosec
+		return fmt.Errorf("can't create directory for %s: %w", target, err)
+	}
+
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode) // nolint:gosec
+	if err != nil {
+		return fmt.Errorf("can't create file %s: %w", target, err)
+	}
+
+	defer func() {
+		closeErr := f.Close()
+
+		if err == nil {
+			err = closeErr
+		}
+	}()
+
+	if _, err := io.Copy(f, r); err != nil { // nolint:gosec
+		return fmt.Errorf("can't write file %s: %w", target, err)
+	}
+
+	return nil
+}
+
+func archivePath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,38 @@
+package gnomock
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// backoff calculates intervals between consecutive health checks. Without
+// max interval set, it always returns the initial interval.
+type backoff struct {
+	interval time.Duration
+	max      time.Duration
+	jitter   float64
+}
+
+func newBackoff(cfg *Options) *backoff {
+	return &backoff{
+		interval: cfg.healthcheckInterval,
+		max:      cfg.healthcheckMaxInterval,
+		jitter:   cfg.healthcheckJitter,
+	}
+}
+
+// next returns the interval to wait before the next health check, and
+// doubles the following one up to the max interval.
+func (b *backoff) next() time.Duration {
+	d := b.interval
+
+	if b.max > b.interval {
+		b.interval = min(b.interval*2, b.max)
+	}
+
+	if b.jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * b.jitter * float64(d)) // nolint:gosec
+	}
+
+	return d
+}
@@ -0,0 +1,85 @@
+package gnomock
+
+import (
+	"context"
+	"fmt"
+)
+
+// Pause suspends all processes of this container. The container keeps its
+// ports bound, but doesn't respond until it is unpaused. Use it to test how
+// the code handles unresponsive dependencies.
+func (c *Container) Pause(ctx context.Context) error {
+	return withDocker(func(cli *docker) error {
+		return cli.pauseContainer(ctx, c.DockerID())
+	})
+}
+
+// Unpause resumes all processes of a paused container.
+func (c *Container) Unpause(ctx context.Context) error {
+	return withDocker(func(cli *docker) error {
+		return cli.unpauseContainer(ctx, c.DockerID())
+	})
+}
+
+// Restart stops this container and starts it again. Once the container is
+// running, its host ports are read again, and Ports are updated if they
+// changed. Proxies created using WithProxy keep their ports. If the container
+// was started by the current process, Restart waits for the healthcheck to
+// pass again before returning, using the same timeout as during the original
+// start. The initial state is not set up again.
+func (c *Container) Restart(ctx context.Context) error {
+	g, err := newG(isInDocker())
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = g.log.Sync() }()
+
+	cli, err := g.dockerConnect()
+	if err != nil {
+		return fmt.Errorf("can't create docker client: %w", err)
+	}
+
+	defer func() { _ = cli.stopClient() }()
+
+	g.log.Infow("restarting", "container", c)
+
+	if err := cli.restartContainer(ctx, c.DockerID()); err != nil {
+		return err
+	}
+
+	// logs collected during the original start are no longer relevant
+	c.logTail = nil
+
+	if len(c.internalPorts) > 0 {
+		restarted, err := g.waitForContainerNetwork(ctx, cli, c.DockerID(), c.internalPorts)
+		if err != nil {
+			return fmt.Errorf("container network isn't ready: %w", err)
+		}
+
+		c.setAddresses(restarted)
+	}
+
+	if c.config == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	if err := g.wait(ctx, cli, c, c.config, nil); err != nil {
+		return fmt.Errorf("can't connect to container: %w", err)
+	}
+
+	return nil
+}
+
+// Kill sends the provided signal, such as "SIGKILL" or "SIGHUP", to the main
+// process of this container. SIGKILL is sent if the signal is empty. A
+// container that exits as a result is kept until Stop, so it can be restarted
+// using Restart.
+func (c *Container) Kill(ctx context.Context, signal string) error {
+	return withDocker(func(cli *docker) error {
+		return cli.killContainer(ctx, c.DockerID(), signal)
+	})
+}
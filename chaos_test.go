@@ -0,0 +1,49 @@
+package gnomock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainer_chaos(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	p := &testutil.TestPreset{Img: testutil.TestImage}
+
+	container, err := gnomock.Start(p)
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, gnomock.Stop(container)) }()
+
+	t.Run("pause and unpause", func(t *testing.T) {
+		require.NoError(t, container.Pause(ctx))
+
+		pausedCtx, cancel := context.WithTimeout(ctx, time.Millisecond*500)
+		defer cancel()
+
+		require.Error(t, testutil.Healthcheck(pausedCtx, container))
+
+		require.NoError(t, container.Unpause(ctx))
+		require.NoError(t, testutil.Healthcheck(ctx, container))
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		require.NoError(t, container.Restart(ctx))
+		require.NoError(t, testutil.Healthcheck(ctx, container))
+	})
+
+	t.Run("kill", func(t *testing.T) {
+		c, err := gnomock.Start(p)
+		require.NoError(t, err)
+
+		require.NoError(t, c.Kill(ctx, "SIGKILL"))
+		require.Error(t, c.Restart(ctx))
+		require.NoError(t, gnomock.Stop(c))
+	})
+}
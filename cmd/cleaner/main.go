@@ -1,7 +1,8 @@
 // This program is intended to run as a sidecar of other, temporary containers.
 // After startup, it begins to listen to incoming HTTP connections on port
 // :8008. Requests to `/sync/:id` endpoint hang until canceled, and then
-// trigger container `:id` to be terminated.
+// trigger container `:id` to be terminated. Requests to `/sync/network/:id`
+// work the same way, but remove network `:id` instead.
 //
 // If this program doesn't get any input for 10 seconds, it halts. It also
 // terminates after an attempt to stop the requested container.
@@ -43,6 +44,21 @@ func main() {
 		once.Do(func() { connected <- true })
 
 		id := strings.TrimPrefix(r.URL.Path, "/sync/")
+
+		if networkID, ok := strings.CutPrefix(id, "network/"); ok {
+			log.Println("got request to remove network", networkID)
+			<-r.Context().Done()
+
+			if err := gnomock.RemoveNetwork(&gnomock.Network{
+				ID: networkID,
+			}); err != nil {
+				log.Fatalf("can't remove network %s: %s\n", networkID, err.Error())
+			}
+
+			log.Println(networkID, "removed, exiting")
+			os.Exit(0)
+		}
+
 		log.Println("got request to kill", id)
 		<-r.Context().Done()
 
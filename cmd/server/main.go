@@ -1,16 +1,25 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/orlangure/gnomock/internal/gnomockd"
 )
 
+// shutdownTimeout limits the time the server waits for active requests to
+// complete before stopping the containers it started.
+const shutdownTimeout = time.Second * 30
+
 var version string
 
 func main() {
@@ -34,6 +43,41 @@ func main() {
 		}
 	}
 
-	addr := fmt.Sprintf(":%d", port)
-	log.Println(http.ListenAndServe(addr, gnomockd.Handler())) // nolint: gosec
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	s := gnomockd.New()
+	srv := &http.Server{ // nolint: gosec
+		Addr:    fmt.Sprintf(":%d", port),
+		Handler: s,
+	}
+	srv.RegisterOnShutdown(s.CloseStreams)
+
+	shutdown := make(chan struct{})
+
+	go func() {
+		defer close(shutdown)
+
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Println("can't shut down gracefully:", err)
+		}
+	}()
+
+	err := srv.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		// wait for active requests to complete, so that containers they
+		// start are stopped as well
+		<-shutdown
+	} else {
+		log.Println(err)
+	}
+
+	if err := s.StopAll(); err != nil {
+		log.Println("can't stop containers:", err)
+	}
 }
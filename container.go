@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/orlangure/gnomock/internal/envaware"
 )
 
 // Container represents a docker container created for testing. Host and Ports
@@ -25,6 +27,35 @@ type Container struct {
 
 	gateway string
 	onStop  func() error
+
+	// address of this container inside the first network it is attached to,
+	// and the original ports exposed by the container, as opposed to ports
+	// bound on the host
+	internalHost  string
+	internalPorts NamedPorts
+
+	// image and configuration used to start this container, if it was
+	// started by the current process
+	image  string
+	config *Options
+
+	// name of the shared container, if it was started using Shared
+	shared string
+
+	// networks created by Up for the environment of this container, removed
+	// by Down
+	envNetworks []*Network
+
+	// reused is true if an existing container was found and reused instead
+	// of creating a new one
+	reused bool
+
+	// proxies in front of container ports, if it was started using
+	// WithProxy, by port name
+	proxies map[string]*Proxy
+
+	// last lines of container logs, collected while the container starts
+	logTail *logTail
 }
 
 // Address is a convenience function that returns host:port that can be used to
@@ -56,12 +87,41 @@ func (c *Container) DefaultPort() int {
 	return c.Port(DefaultPort)
 }
 
+// InternalAddress returns host:port that can be used to connect to this
+// container from other containers attached to the same docker network. It
+// uses the first network alias as host name, or the IP address of the
+// container in that network if there are no aliases. An empty string is
+// returned if the container is not attached to any network, or if there is
+// no port with the provided name.
+func (c *Container) InternalAddress(name string) string {
+	if c.internalHost == "" {
+		return ""
+	}
+
+	p := c.internalPorts.Get(name).Port
+	if p == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("%s:%d", c.internalHost, p)
+}
+
 // DockerID returns the ID of this container as known to Docker.
 func (c *Container) DockerID() string {
 	id, _ := parseID(c.ID)
 	return id
 }
 
+func init() {
+	envaware.Clone = func(c any) any {
+		if c, ok := c.(*Container); ok {
+			return envAwareClone(c)
+		}
+
+		return c
+	}
+}
+
 func isInDocker() bool {
 	env := os.Getenv("GNOMOCK_ENV")
 	return env == "gnomockd"
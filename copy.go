@@ -0,0 +1,21 @@
+package gnomock
+
+import "context"
+
+// CopyTo copies a file or a directory located at `src` on the machine running
+// Gnomock into this container under `dst` path. Missing parent directories are
+// created automatically.
+func (c *Container) CopyTo(ctx context.Context, src, dst string) error {
+	return withDocker(func(cli *docker) error {
+		return cli.copyFiles(ctx, c.DockerID(), nil, map[string]string{dst: src})
+	})
+}
+
+// CopyFrom copies a file or a directory located at `src` inside this container
+// to `dst` path on the machine running Gnomock. Existing files are
+// overwritten.
+func (c *Container) CopyFrom(ctx context.Context, src, dst string) error {
+	return withDocker(func(cli *docker) error {
+		return cli.copyFromContainer(ctx, c.DockerID(), src, dst)
+	})
+}
@@ -0,0 +1,70 @@
+package gnomock_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGnomock_withFiles(t *testing.T) {
+	t.Parallel()
+
+	src := filepath.Join(t.TempDir(), "from-host.txt")
+	require.NoError(t, os.WriteFile(src, []byte("from host"), 0o600))
+
+	container, err := gnomock.StartCustom(
+		"docker.io/library/busybox:1.35.0",
+		gnomock.DefaultTCP(testutil.GoodPort80),
+		gnomock.WithFiles(map[string][]byte{
+			"/etc/gnomock/config.txt": []byte("from memory"),
+		}),
+		gnomock.WithFileFrom(src, "/etc/gnomock/host.txt"),
+		gnomock.WithCommand("sleep", "60"),
+	)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, gnomock.Stop(container))
+	}()
+
+	ctx := context.Background()
+
+	stdout, _, code, err := container.Exec(ctx, []string{"cat", "/etc/gnomock/config.txt"})
+	require.NoError(t, err)
+	require.Equal(t, 0, code)
+	require.Equal(t, "from memory", string(stdout))
+
+	t.Run("copy to and from container", func(t *testing.T) {
+		require.NoError(t, container.CopyTo(ctx, src, "/tmp/copied.txt"))
+
+		dst := filepath.Join(t.TempDir(), "copied-back.txt")
+		require.NoError(t, container.CopyFrom(ctx, "/tmp/copied.txt", dst))
+
+		content, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		require.Equal(t, "from host", string(content))
+	})
+
+	t.Run("copy directory from container", func(t *testing.T) {
+		dst := filepath.Join(t.TempDir(), "gnomock")
+		require.NoError(t, container.CopyFrom(ctx, "/etc/gnomock", dst))
+
+		content, err := os.ReadFile(filepath.Join(dst, "host.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "from host", string(content))
+	})
+
+	t.Run("missing host file", func(t *testing.T) {
+		c, err := gnomock.StartCustom(
+			testutil.TestImage, gnomock.DefaultTCP(testutil.GoodPort80),
+			gnomock.WithFileFrom(filepath.Join(t.TempDir(), "missing"), "/tmp/missing"),
+		)
+		require.Error(t, err)
+		require.Nil(t, c)
+	})
+}
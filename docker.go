@@ -1,7 +1,9 @@
 package gnomock
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -15,10 +17,12 @@ import (
 	"time"
 
 	cerrdefs "github.com/containerd/errdefs"
+	"github.com/moby/moby/api/pkg/stdcopy"
 	"github.com/moby/moby/api/types/container"
 	"github.com/moby/moby/api/types/mount"
 	"github.com/moby/moby/api/types/network"
 	"github.com/moby/moby/client"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 	"github.com/orlangure/gnomock/internal/cleaner"
 	"github.com/orlangure/gnomock/internal/health"
 	"go.uber.org/zap"
@@ -62,7 +66,27 @@ func (g *g) dockerConnect() (*docker, error) {
 	return &docker{client: cli, log: g.log}, nil
 }
 
-func (d *docker) isExistingLocalImage(ctx context.Context, image string) (bool, error) {
+// withDocker connects to docker engine and calls f with the new client. The
+// client is closed when f returns.
+func withDocker(f func(*docker) error) error {
+	g, err := newG(isInDocker())
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = g.log.Sync() }()
+
+	cli, err := g.dockerConnect()
+	if err != nil {
+		return fmt.Errorf("can't create docker client: %w", err)
+	}
+
+	defer func() { _ = cli.stopClient() }()
+
+	return f(cli)
+}
+
+func (d *docker) isExistingLocalImage(ctx context.Context, image string, platform *ocispec.Platform) (bool, error) {
 	result, err := d.client.ImageList(ctx, client.ImageListOptions{All: true})
 	if err != nil {
 		return false, fmt.Errorf("can't list image: %w", err)
@@ -71,7 +95,7 @@ func (d *docker) isExistingLocalImage(ctx context.Context, image string) (bool,
 	for _, img := range result.Items {
 		for _, repoTag := range img.RepoTags {
 			if image == repoTag {
-				return true, nil
+				return d.isExistingLocalPlatform(ctx, img.ID, platform)
 			}
 
 			if !strings.Contains(repoTag, "/") {
@@ -79,7 +103,7 @@ func (d *docker) isExistingLocalImage(ctx context.Context, image string) (bool,
 			}
 
 			if strings.HasSuffix(image, repoTag) {
-				return true, nil
+				return d.isExistingLocalPlatform(ctx, img.ID, platform)
 			}
 		}
 	}
@@ -87,12 +111,41 @@ func (d *docker) isExistingLocalImage(ctx context.Context, image string) (bool,
 	return false, nil
 }
 
-func (d *docker) pullImage(ctx context.Context, image string, cfg *Options) error {
+// isExistingLocalPlatform checks whether a local image with the provided id
+// is available for the requested platform. Any platform is accepted if it is
+// not set.
+func (d *docker) isExistingLocalPlatform(ctx context.Context, id string, platform *ocispec.Platform) (bool, error) {
+	if platform == nil {
+		return true, nil
+	}
+
+	_, err := d.client.ImageInspect(ctx, id, client.ImageInspectWithPlatform(platform))
+	if cerrdefs.IsNotFound(err) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("can't inspect image %s: %w", id, err)
+	}
+
+	return true, nil
+}
+
+func (d *docker) pullImage(ctx context.Context, image string, platform *ocispec.Platform, cfg *Options) error {
 	d.log.Info("pulling image")
 
-	resp, err := d.client.ImagePull(ctx, image, client.ImagePullOptions{
+	start := time.Now()
+	cfg.Emit(Event{Type: EventImagePullStarted, Image: image})
+
+	pullOpts := client.ImagePullOptions{
 		RegistryAuth: cfg.Auth,
-	})
+	}
+
+	if platform != nil {
+		pullOpts.Platforms = []ocispec.Platform{*platform}
+	}
+
+	resp, err := d.client.ImagePull(ctx, image, pullOpts)
 	if err != nil {
 		return fmt.Errorf("can't pull image: %w", err)
 	}
@@ -105,83 +158,106 @@ func (d *docker) pullImage(ctx context.Context, image string, cfg *Options) erro
 		}
 	}()
 
-	_, err = io.ReadAll(resp)
+	err = readPullProgress(resp, func(msg string) {
+		cfg.ReportPullProgress(image, msg)
+	})
 	if err != nil {
 		return fmt.Errorf("can't read server output: %w", err)
 	}
 
 	d.log.Info("image pulled")
+	cfg.Emit(Event{Type: EventImagePullDone, Image: image, Duration: time.Since(start)})
 
 	return nil
 }
 
-func (d *docker) startContainer(ctx context.Context, image string, ports NamedPorts, cfg *Options) (*Container, error) {
-	if cfg.Reuse {
-		container, ok, err := d.findReusableContainer(ctx, image, ports, cfg)
-		if err != nil {
-			return nil, err
-		}
+// pullMessage is a single progress update sent by docker during image pull.
+type pullMessage struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	Progress string `json:"progress"`
+}
 
-		if ok {
-			d.log.Info("re-using container")
-			return container, nil
+func (m pullMessage) String() string {
+	parts := make([]string, 0, 3)
+
+	for _, part := range []string{m.ID, m.Status, m.Progress} {
+		if part != "" {
+			parts = append(parts, part)
 		}
 	}
 
-	d.log.Info("starting container")
+	return strings.Join(parts, " ")
+}
 
-	resp, err := d.prepareContainer(ctx, image, ports, cfg)
-	if err != nil {
-		return nil, fmt.Errorf("can't prepare container: %w", err)
-	}
+// readPullProgress reads image pull output until it ends, and calls progress
+// for every received update.
+func readPullProgress(r io.Reader, progress func(string)) error {
+	decoder := json.NewDecoder(r)
 
-	sidecarChan, cleanupCancel := d.setupContainerCleanup(resp.ID, cfg)
+	for {
+		var msg pullMessage
 
-	_, err = d.client.ContainerStart(ctx, resp.ID, client.ContainerStartOptions{})
-	if err != nil {
-		cleanupCancel()
-		return nil, fmt.Errorf("can't start container %s: %w", resp.ID, err)
-	}
+		err := decoder.Decode(&msg)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
 
-	container, err := d.waitForContainerNetwork(ctx, resp.ID, ports)
-	if err != nil {
-		cleanupCancel()
-		return nil, fmt.Errorf("container network isn't ready: %w", err)
-	}
+		if err != nil {
+			return err
+		}
 
-	if sidecar, ok := <-sidecarChan; ok {
-		container.ID = generateID(container.ID, sidecar)
+		progress(msg.String())
 	}
+}
 
-	d.log.Infow("container started", "container", container)
+func setupContainerCleanup(id string, cfg *Options) (chan string, context.CancelFunc) {
+	return setupCleanup(cfg, func(ctx context.Context, addr string) error {
+		return cleaner.Notify(ctx, addr, id)
+	})
+}
 
-	return container, nil
+func setupNetworkCleanup(id string, cfg *Options) (chan string, context.CancelFunc) {
+	return setupCleanup(cfg, func(ctx context.Context, addr string) error {
+		return cleaner.NotifyNetwork(ctx, addr, id)
+	})
 }
 
-func (d *docker) setupContainerCleanup(id string, cfg *Options) (chan string, context.CancelFunc) {
+// setupCleanup starts a cleaner sidecar container in the background, and
+// calls notify with its address once it is ready. The ID of the sidecar is
+// sent to the returned channel, which is closed if no sidecar was started.
+// The sidecar needs docker engine, so it is never started when a custom
+// runtime is used.
+func setupCleanup(
+	cfg *Options,
+	notify func(ctx context.Context, addr string) error,
+) (chan string, context.CancelFunc) {
 	sidecarChan := make(chan string, 1)
 	bctx, bcancel := context.WithCancel(context.Background())
 
 	go func() {
 		defer close(sidecarChan)
 
-		if cfg.DisableAutoCleanup || cfg.Reuse || cfg.Debug {
+		if cfg.DisableAutoCleanup || cfg.Reuse || cfg.Debug || cfg.runtime != nil {
 			return
 		}
 
 		opts := []Option{
 			WithDisableAutoCleanup(),
+			// the sidecar exits by itself once it is done, and its exit code
+			// is of no interest
+			func(o *Options) { o.autoRemove = true },
 			WithHostMounts(dockerSockAddr, dockerSockAddr),
 			WithHealthCheck(func(ctx context.Context, c *Container) error {
 				return health.HTTPGet(ctx, c.DefaultAddress())
 			}),
 			WithInit(func(_ context.Context, c *Container) error {
-				return cleaner.Notify(bctx, c.DefaultAddress(), id)
+				return notify(bctx, c.DefaultAddress())
 			}),
 			WithContext(bctx),
 		}
-		if cfg.UseLocalImagesFirst {
-			opts = append(opts, WithUseLocalImagesFirst())
+		if policy := cfg.pullPolicy(); policy != PullAlways {
+			opts = append(opts, WithPullPolicy(policy))
 		}
 
 		if sc, err := StartCustom(
@@ -195,81 +271,102 @@ func (d *docker) setupContainerCleanup(id string, cfg *Options) (chan string, co
 	return sidecarChan, bcancel
 }
 
-func (d *docker) prepareContainer(
-	ctx context.Context,
-	image string,
-	ports NamedPorts,
-	cfg *Options,
-) (*client.ContainerCreateResult, error) {
-	pullImage := true
+// PullImage pulls the image according to the pull policy and the platform
+// set in the provided options.
+func (d *docker) PullImage(ctx context.Context, image string, cfg *Options) error {
+	platform, err := cfg.platform()
+	if err != nil {
+		return err
+	}
 
-	if cfg.UseLocalImagesFirst {
-		isExisting, err := d.isExistingLocalImage(ctx, image)
+	if policy := cfg.pullPolicy(); policy != PullAlways {
+		isExisting, err := d.isExistingLocalImage(ctx, image, platform)
 		if err != nil {
-			return nil, fmt.Errorf("can't list image: %w", err)
+			return fmt.Errorf("can't list image: %w", err)
 		}
 
 		if isExisting {
-			pullImage = false
+			return nil
 		}
-	}
 
-	if pullImage {
-		if err := d.pullImage(ctx, image, cfg); err != nil {
-			return nil, fmt.Errorf("can't pull image: %w", err)
+		if policy == PullNever {
+			return fmt.Errorf("%w: %s", ErrImageNotPresent, image)
 		}
 	}
 
-	resp, err := d.createContainer(ctx, image, ports, cfg)
+	return d.pullImage(ctx, image, platform, cfg)
+}
+
+// CreateContainer creates a new container and copies the files set in the
+// provided options into it.
+func (d *docker) CreateContainer(ctx context.Context, image string, ports NamedPorts, cfg *Options) (string, error) {
+	platform, err := cfg.platform()
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := d.createContainer(ctx, image, ports, platform, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("can't create container: %w", err)
+		return "", err
+	}
+
+	if len(cfg.Files) > 0 || len(cfg.filesFrom) > 0 {
+		err = d.copyFiles(ctx, resp.ID, cfg.Files, cfg.filesFrom)
+		if err != nil {
+			_ = d.RemoveContainer(context.Background(), resp.ID)
+			return "", fmt.Errorf("can't copy files: %w", err)
+		}
 	}
 
-	return resp, err
+	return resp.ID, nil
 }
 
-func (d *docker) waitForContainerNetwork(ctx context.Context, id string, ports NamedPorts) (*Container, error) {
-	d.log.Infow("waiting for container network", "container", id)
+// StartContainer starts a created container.
+func (d *docker) StartContainer(ctx context.Context, id string) error {
+	_, err := d.client.ContainerStart(ctx, id, client.ContainerStartOptions{})
+	if err != nil {
+		return fmt.Errorf("can't start container %s: %w", id, err)
+	}
 
-	tick := time.NewTicker(time.Millisecond * 250)
-	defer tick.Stop()
+	return nil
+}
 
-	for {
-		select {
-		case <-ctx.Done():
-			return nil, fmt.Errorf("container network is unavailable after timeout")
-		case <-tick.C:
-			inspectResult, err := d.client.ContainerInspect(ctx, id, client.ContainerInspectOptions{})
-			if err != nil {
-				return nil, fmt.Errorf("can't inspect container %s: %w", id, err)
-			}
+// InspectContainer returns the current state of the container, including the
+// host ports bound to the provided container ports.
+func (d *docker) InspectContainer(ctx context.Context, id string, ports NamedPorts) (*ContainerState, error) {
+	inspectResult, err := d.client.ContainerInspect(ctx, id, client.ContainerInspectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("can't inspect container %s: %w", id, err)
+	}
 
-			boundNamedPorts, err := d.boundNamedPorts(inspectResult.Container, ports)
-			if err != nil {
-				return nil, fmt.Errorf("can't find bound ports: %w", err)
-			}
+	boundNamedPorts, err := d.boundNamedPorts(inspectResult.Container, ports)
+	if err != nil {
+		return nil, fmt.Errorf("can't find bound ports: %w", err)
+	}
 
-			d.log.Infow("waiting for port allocation", "container", id)
+	state := &ContainerState{
+		Host:  d.hostAddr(),
+		Ports: boundNamedPorts,
+	}
 
-			if len(boundNamedPorts) == len(ports) {
-				var gateway string
+	for _, ep := range inspectResult.Container.NetworkSettings.Networks {
+		if ep != nil && ep.Gateway.IsValid() {
+			state.Gateway = ep.Gateway.String()
+			break
+		}
+	}
 
-				for _, ep := range inspectResult.Container.NetworkSettings.Networks {
-					if ep != nil && ep.Gateway.IsValid() {
-						gateway = ep.Gateway.String()
-						break
-					}
-				}
+	if s := inspectResult.Container.State; s != nil {
+		state.Exited = s.Status == container.StateExited || s.Status == container.StateDead
+		state.ExitCode = s.ExitCode
+		state.OOMKilled = s.OOMKilled
 
-				return &Container{
-					ID:      id,
-					Host:    d.hostAddr(),
-					Ports:   boundNamedPorts,
-					gateway: gateway,
-				}, nil
-			}
+		if s.Health != nil {
+			state.Health = string(s.Health.Status)
 		}
 	}
+
+	return state, nil
 }
 
 func (d *docker) exposedPorts(namedPorts NamedPorts) network.PortSet {
@@ -329,6 +426,7 @@ func (d *docker) createContainer(
 	ctx context.Context,
 	image string,
 	ports NamedPorts,
+	platform *ocispec.Platform,
 	cfg *Options,
 ) (*client.ContainerCreateResult, error) {
 	exposedPorts := d.exposedPorts(ports)
@@ -336,6 +434,7 @@ func (d *docker) createContainer(
 		ExposedPorts: exposedPorts,
 		Env:          cfg.Env,
 		User:         cfg.User,
+		Labels:       cfg.labels(),
 	}
 
 	if len(cfg.Cmd) > 0 {
@@ -346,6 +445,13 @@ func (d *docker) createContainer(
 		containerConfig.Entrypoint = cfg.Entrypoint
 	}
 
+	if len(cfg.dockerHealthcheckCmd) > 0 {
+		containerConfig.Healthcheck = &container.HealthConfig{
+			Test:     append([]string{"CMD"}, cfg.dockerHealthcheckCmd...),
+			Interval: cfg.healthcheckInterval,
+		}
+	}
+
 	mounts := []mount.Mount{}
 	for src, dst := range cfg.HostMounts {
 		mounts = append(mounts, mount.Mount{
@@ -358,17 +464,39 @@ func (d *docker) createContainer(
 	portBindings := d.portBindings(exposedPorts, ports)
 	hostConfig := &container.HostConfig{
 		PortBindings: portBindings,
-		AutoRemove:   !cfg.Debug,
+		AutoRemove:   cfg.autoRemove,
 		Privileged:   cfg.Privileged,
 		Mounts:       mounts,
 		ExtraHosts:   cfg.ExtraHosts,
+		ShmSize:      cfg.ShmSize,
+		Tmpfs:        cfg.Tmpfs,
+		Resources:    d.resources(cfg),
+	}
+
+	var networkingConfig *network.NetworkingConfig
+
+	if names := cfg.networkNames(); len(names) > 0 {
+		// the first network replaces the default bridge network, others are
+		// attached in addition to it
+		hostConfig.NetworkMode = container.NetworkMode(names[0])
+		networkingConfig = &network.NetworkingConfig{
+			EndpointsConfig: make(map[string]*network.EndpointSettings, len(names)),
+		}
+
+		for _, name := range names {
+			networkingConfig.EndpointsConfig[name] = &network.EndpointSettings{
+				Aliases: cfg.Networks[name],
+			}
+		}
 	}
 
 	createOpts := client.ContainerCreateOptions{
-		Config:     containerConfig,
-		HostConfig: hostConfig,
-		Name:       cfg.ContainerName,
-		Image:      image,
+		Config:           containerConfig,
+		HostConfig:       hostConfig,
+		NetworkingConfig: networkingConfig,
+		Platform:         platform,
+		Name:             cfg.ContainerName,
+		Image:            image,
 	}
 
 	resp, err := d.client.ContainerCreate(ctx, createOpts)
@@ -393,14 +521,160 @@ func (d *docker) createContainer(
 	return &resp, err
 }
 
-func (d *docker) findReusableContainer(
-	ctx context.Context,
-	image string,
-	ports NamedPorts,
-	cfg *Options,
-) (*Container, bool, error) {
+// resources returns resource limits of a new container.
+func (d *docker) resources(cfg *Options) container.Resources {
+	resources := container.Resources{
+		Memory:   cfg.MemoryLimit,
+		NanoCPUs: int64(cfg.CPUs * 1e9),
+	}
+
+	for _, ulimit := range cfg.Ulimits {
+		resources.Ulimits = append(resources.Ulimits, &container.Ulimit{
+			Name: ulimit.Name,
+			Soft: ulimit.Soft,
+			Hard: ulimit.Hard,
+		})
+	}
+
+	return resources
+}
+
+// setupInternalAddress saves the address of the container inside the first
+// network it is attached to. The first alias is used as host name if it
+// exists, otherwise the IP address of the container in that network is used.
+func (d *docker) setupInternalAddress(ctx context.Context, c *Container, cfg *Options) error {
+	names := cfg.networkNames()
+	if len(names) == 0 {
+		return nil
+	}
+
+	if aliases := cfg.Networks[names[0]]; len(aliases) > 0 {
+		c.internalHost = aliases[0]
+		return nil
+	}
+
+	inspectResult, err := d.client.ContainerInspect(ctx, c.DockerID(), client.ContainerInspectOptions{})
+	if err != nil {
+		return fmt.Errorf("can't inspect container %s: %w", c.DockerID(), err)
+	}
+
+	ep, ok := inspectResult.Container.NetworkSettings.Networks[names[0]]
+	if !ok || ep == nil || !ep.IPAddress.IsValid() {
+		return fmt.Errorf("container is not attached to network %s", names[0])
+	}
+
+	c.internalHost = ep.IPAddress.String()
+
+	return nil
+}
+
+func (d *docker) createNetwork(ctx context.Context, name string, cfg *Options) (*Network, error) {
+	d.log.Infow("creating network", "name", name)
+
+	resp, err := d.client.NetworkCreate(ctx, name, client.NetworkCreateOptions{
+		Driver: "bridge",
+		Labels: cfg.labels(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't create network %s: %w", name, err)
+	}
+
+	sidecarChan, cleanupCancel := setupNetworkCleanup(resp.ID, cfg)
+	n := &Network{ID: resp.ID, Name: name, cleanupCancel: cleanupCancel}
+
+	if sidecar, ok := <-sidecarChan; ok {
+		n.ID = generateID(n.ID, sidecar)
+	}
+
+	d.log.Infow("network created", "network", n)
+
+	return n, nil
+}
+
+// removeNetwork disconnects all the containers attached to the network with
+// the provided id, and then removes the network itself.
+func (d *docker) removeNetwork(ctx context.Context, id string) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	inspectResult, err := d.client.NetworkInspect(ctx, id, client.NetworkInspectOptions{})
+	if err != nil {
+		if cerrdefs.IsNotFound(err) {
+			return nil
+		}
+
+		return fmt.Errorf("can't inspect network %s: %w", id, err)
+	}
+
+	for containerID := range inspectResult.Network.Containers {
+		_, err = d.client.NetworkDisconnect(ctx, id, client.NetworkDisconnectOptions{
+			Container: containerID,
+			Force:     true,
+		})
+		if err != nil && !cerrdefs.IsNotFound(err) {
+			return fmt.Errorf("can't disconnect container %s from network %s: %w", containerID, id, err)
+		}
+	}
+
+	_, err = d.client.NetworkRemove(ctx, id, client.NetworkRemoveOptions{})
+	if err != nil && !cerrdefs.IsNotFound(err) {
+		return fmt.Errorf("can't remove network %s: %w", id, err)
+	}
+
+	return nil
+}
+
+// sweep removes containers and networks created by other Gnomock sessions
+// before the provided time.
+func (d *docker) sweep(ctx context.Context, before time.Time) error {
+	filters := make(client.Filters).Add("label", LabelSession)
+
+	containers, err := d.client.ContainerList(ctx, client.ContainerListOptions{
+		All:     true,
+		Filters: filters,
+	})
+	if err != nil {
+		return fmt.Errorf("can't list containers: %w", err)
+	}
+
+	for _, c := range containers.Items {
+		if c.Labels[LabelSession] == session || c.Labels[LabelReuse] != "" ||
+			!time.Unix(c.Created, 0).Before(before) {
+			continue
+		}
+
+		d.log.Infow("sweeping container", "container", c.ID)
+
+		if err := d.RemoveContainer(ctx, c.ID); err != nil {
+			return err
+		}
+	}
+
+	networks, err := d.client.NetworkList(ctx, client.NetworkListOptions{Filters: filters})
+	if err != nil {
+		return fmt.Errorf("can't list networks: %w", err)
+	}
+
+	for _, n := range networks.Items {
+		if n.Labels[LabelSession] == session || !n.Created.Before(before) {
+			continue
+		}
+
+		d.log.Infow("sweeping network", "network", n.ID)
+
+		if err := d.removeNetwork(ctx, n.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findReusableContainer returns the ID of a running container created from
+// the image with the name set in the provided options.
+func (d *docker) findReusableContainer(ctx context.Context, image string, cfg *Options) (string, bool, error) {
 	if cfg.ContainerName == "" {
-		return nil, false, fmt.Errorf("container name is required when container reuse is enabled")
+		return "", false, fmt.Errorf("container name is required when container reuse is enabled")
 	}
 
 	result, err := d.client.ContainerList(ctx, client.ContainerListOptions{
@@ -410,15 +684,10 @@ func (d *docker) findReusableContainer(
 			Add("status", "running"),
 	})
 	if err != nil || len(result.Items) < 1 {
-		return nil, false, err
+		return "", false, err
 	}
 
-	container, err := d.waitForContainerNetwork(ctx, result.Items[0].ID, ports)
-	if err != nil {
-		return nil, false, err
-	}
-
-	return container, true, nil
+	return result.Items[0].ID, true, nil
 }
 
 func (d *docker) boundNamedPorts(inspectResp container.InspectResponse, namedPorts NamedPorts) (NamedPorts, error) {
@@ -451,7 +720,10 @@ func (d *docker) boundNamedPorts(inspectResp container.InspectResponse, namedPor
 	return boundNamedPorts, nil
 }
 
-func (d *docker) readLogs(ctx context.Context, id string) (io.ReadCloser, error) {
+// ContainerLogs returns a stream of container logs. Docker multiplexes
+// stdout and stderr into a single stream, so the returned reader demultiplexes
+// them back into plain text.
+func (d *docker) ContainerLogs(ctx context.Context, id string) (io.ReadCloser, error) {
 	d.log.Info("starting container logs forwarder")
 
 	logsOptions := client.ContainerLogsOptions{
@@ -463,12 +735,186 @@ func (d *docker) readLogs(ctx context.Context, id string) (io.ReadCloser, error)
 		return nil, fmt.Errorf("can't read logs: %w", err)
 	}
 
+	pr, pw := io.Pipe()
+
+	go func() {
+		_, err := stdcopy.StdCopy(pw, pw, rc)
+		_ = pw.CloseWithError(err)
+	}()
+
 	d.log.Info("container logs forwarder ready")
 
-	return rc, nil
+	return &logReader{PipeReader: pr, src: rc}, nil
+}
+
+// logReader reads demultiplexed container logs. Closing it also closes the
+// original log stream.
+type logReader struct {
+	*io.PipeReader
+	src io.Closer
+}
+
+func (r *logReader) Close() error {
+	_ = r.PipeReader.Close()
+	return r.src.Close()
+}
+
+// copyFiles uploads the provided files into the container with the provided
+// id. See filesArchive for the format of the files.
+func (d *docker) copyFiles(ctx context.Context, id string, files map[string][]byte, hostFiles map[string]string) error {
+	d.log.Infow("copying files", "container", id)
+
+	archive, err := filesArchive(files, hostFiles)
+	if err != nil {
+		return err
+	}
+
+	_, err = d.client.CopyToContainer(ctx, id, client.CopyToContainerOptions{
+		DestinationPath: "/",
+		Content:         archive,
+	})
+	if err != nil {
+		return fmt.Errorf("can't copy files to container %s: %w", id, err)
+	}
+
+	return nil
+}
+
+// copyFromContainer downloads a file or a directory from the container with
+// the provided id, and saves it on the host.
+func (d *docker) copyFromContainer(ctx context.Context, id, src, dst string) error {
+	d.log.Infow("copying files from container", "container", id, "src", src)
+
+	result, err := d.client.CopyFromContainer(ctx, id, client.CopyFromContainerOptions{
+		SourcePath: src,
+	})
+	if err != nil {
+		return fmt.Errorf("can't copy %s from container %s: %w", src, id, err)
+	}
+
+	defer func() { _ = result.Content.Close() }()
+
+	return extractArchive(result.Content, dst)
+}
+
+// commitContainer saves the current state of the container with the provided
+// id as a new local image. The named ports of the container are kept in the
+// image labels, so that new containers can be created from this image without
+// providing the ports again. Labels Gnomock sets on its containers are not
+// kept, so that containers created from the image are not mistaken for the
+// original one, for example by container reuse or cleanup.
+func (d *docker) commitContainer(ctx context.Context, id, image string, ports NamedPorts) error {
+	d.log.Infow("committing container", "container", id, "image", image)
+
+	inspectResult, err := d.client.ContainerInspect(ctx, id, client.ContainerInspectOptions{})
+	if err != nil {
+		return fmt.Errorf("can't inspect container %s: %w", id, err)
+	}
+
+	config := inspectResult.Container.Config
+	if config == nil {
+		config = &container.Config{}
+	}
+
+	for _, label := range []string{LabelSession, LabelPreset, LabelCreated, LabelBinary, LabelReuse} {
+		delete(config.Labels, label)
+	}
+
+	if len(ports) > 0 {
+		bs, err := json.Marshal(ports)
+		if err != nil {
+			return fmt.Errorf("can't encode ports: %w", err)
+		}
+
+		if config.Labels == nil {
+			config.Labels = make(map[string]string)
+		}
+
+		config.Labels[snapshotPortsLabel] = string(bs)
+	}
+
+	_, err = d.client.ContainerCommit(ctx, id, client.ContainerCommitOptions{
+		Reference: image,
+		Config:    config,
+	})
+	if err != nil {
+		return fmt.Errorf("can't commit container %s: %w", id, err)
+	}
+
+	return nil
+}
+
+// snapshotPorts returns named ports saved in the labels of a snapshot image.
+func (d *docker) snapshotPorts(ctx context.Context, image string) (NamedPorts, error) {
+	inspectResult, err := d.client.ImageInspect(ctx, image)
+	if err != nil {
+		return nil, fmt.Errorf("can't inspect image %s: %w", image, err)
+	}
+
+	var ports NamedPorts
+
+	if cfg := inspectResult.Config; cfg != nil {
+		if label, ok := cfg.Labels[snapshotPortsLabel]; ok {
+			if err := json.Unmarshal([]byte(label), &ports); err != nil {
+				return nil, fmt.Errorf("can't decode ports of image %s: %w", image, err)
+			}
+		}
+	}
+
+	return ports, nil
+}
+
+// exec runs the provided command inside a running container with the provided
+// id, and copies its output into stdout and stderr. It returns the exit code
+// of the command once it completes.
+func (d *docker) exec(ctx context.Context, id string, cmd []string, stdout, stderr io.Writer) (int, error) {
+	d.log.Infow("executing command", "container", id, "cmd", cmd)
+
+	execResult, err := d.client.ExecCreate(ctx, id, client.ExecCreateOptions{
+		AttachStdout: true,
+		AttachStderr: true,
+		Cmd:          cmd,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("can't create exec in container %s: %w", id, err)
+	}
+
+	attachResult, err := d.client.ExecAttach(ctx, execResult.ID, client.ExecAttachOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("can't attach to exec %s: %w", execResult.ID, err)
+	}
+
+	defer attachResult.Close()
+
+	copyErr := make(chan error, 1)
+
+	go func() {
+		_, err := stdcopy.StdCopy(stdout, stderr, attachResult.Reader)
+		copyErr <- err
+	}()
+
+	select {
+	case <-ctx.Done():
+		return 0, fmt.Errorf("exec canceled: %w", ctx.Err())
+	case err := <-copyErr:
+		if err != nil && !errors.Is(err, io.EOF) {
+			return 0, fmt.Errorf("can't read exec output: %w", err)
+		}
+	}
+
+	inspectResult, err := d.client.ExecInspect(ctx, execResult.ID, client.ExecInspectOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("can't inspect exec %s: %w", execResult.ID, err)
+	}
+
+	d.log.Infow("command executed", "container", id, "exit_code", inspectResult.ExitCode)
+
+	return inspectResult.ExitCode, nil
 }
 
-func (d *docker) stopContainer(ctx context.Context, id string) error {
+// StopContainer stops a running container. It doesn't fail if the container
+// no longer exists.
+func (d *docker) StopContainer(ctx context.Context, id string) error {
 	d.lock.Lock()
 	defer d.lock.Unlock()
 
@@ -484,11 +930,163 @@ func (d *docker) stopContainer(ctx context.Context, id string) error {
 	return nil
 }
 
+// runNetworkSidecar runs the provided shell script in a short-lived sidecar
+// container created from the provided image. The sidecar shares the network
+// namespace of the container with the provided id, and runNetworkSidecar
+// waits for the script to complete.
+func (d *docker) runNetworkSidecar(ctx context.Context, id, image, script string) error {
+	cfg := buildConfig(WithPullPolicy(PullIfNotPresent))
+
+	if err := d.PullImage(ctx, image, cfg); err != nil {
+		return fmt.Errorf("can't pull image: %w", err)
+	}
+
+	resp, err := d.client.ContainerCreate(ctx, client.ContainerCreateOptions{
+		Config: &container.Config{
+			Entrypoint: []string{"sh", "-c"},
+			Cmd:        []string{script},
+			Labels:     cfg.labels(),
+		},
+		HostConfig: &container.HostConfig{
+			NetworkMode: container.NetworkMode("container:" + id),
+			CapAdd:      []string{"NET_ADMIN"},
+		},
+		Image: image,
+	})
+	if err != nil {
+		return fmt.Errorf("can't create network sidecar for container %s: %w", id, err)
+	}
+
+	defer func() { _ = d.RemoveContainer(context.Background(), resp.ID) }()
+
+	wait := d.client.ContainerWait(ctx, resp.ID, client.ContainerWaitOptions{
+		Condition: container.WaitConditionNextExit,
+	})
+
+	if err := d.StartContainer(ctx, resp.ID); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("network sidecar canceled: %w", ctx.Err())
+	case err := <-wait.Error:
+		return fmt.Errorf("can't wait for network sidecar: %w", err)
+	case result := <-wait.Result:
+		if result.StatusCode != 0 {
+			return fmt.Errorf(
+				"network sidecar failed with exit code %d: %s",
+				result.StatusCode, d.containerOutput(ctx, resp.ID),
+			)
+		}
+	}
+
+	return nil
+}
+
+// containerOutput returns everything the container with the provided id
+// wrote to stdout and stderr, or an empty string if it can't be read.
+func (d *docker) containerOutput(ctx context.Context, id string) string {
+	out, err := d.logs(ctx, id)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// logs returns everything the container with the provided id wrote to stdout
+// and stderr so far.
+func (d *docker) logs(ctx context.Context, id string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := d.streamLogs(ctx, id, &buf, &buf, time.Time{}, false); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// streamLogs writes the logs of the container with the provided id, starting
+// at since, into stdout and stderr. Zero since includes all the logs. If
+// follow is set, it keeps writing new logs until the container stops or the
+// context is canceled.
+func (d *docker) streamLogs(
+	ctx context.Context,
+	id string,
+	stdout, stderr io.Writer,
+	since time.Time,
+	follow bool,
+) error {
+	opts := client.ContainerLogsOptions{
+		ShowStdout: true, ShowStderr: true, Follow: follow,
+	}
+
+	if !since.IsZero() {
+		opts.Since = fmt.Sprintf("%d.%09d", since.Unix(), since.Nanosecond())
+	}
+
+	rc, err := d.client.ContainerLogs(ctx, id, opts)
+	if err != nil {
+		return fmt.Errorf("can't read logs: %w", err)
+	}
+
+	defer func() { _ = rc.Close() }()
+
+	if _, err := stdcopy.StdCopy(stdout, stderr, rc); err != nil {
+		return fmt.Errorf("can't read logs: %w", err)
+	}
+
+	return nil
+}
+
+func (d *docker) pauseContainer(ctx context.Context, id string) error {
+	_, err := d.client.ContainerPause(ctx, id, client.ContainerPauseOptions{})
+	if err != nil {
+		return fmt.Errorf("can't pause container %s: %w", id, err)
+	}
+
+	return nil
+}
+
+func (d *docker) unpauseContainer(ctx context.Context, id string) error {
+	_, err := d.client.ContainerUnpause(ctx, id, client.ContainerUnpauseOptions{})
+	if err != nil {
+		return fmt.Errorf("can't unpause container %s: %w", id, err)
+	}
+
+	return nil
+}
+
+func (d *docker) restartContainer(ctx context.Context, id string) error {
+	stopTimeout := defaultStopTimeoutSec
+
+	_, err := d.client.ContainerRestart(ctx, id, client.ContainerRestartOptions{
+		Timeout: &stopTimeout,
+	})
+	if err != nil {
+		return fmt.Errorf("can't restart container %s: %w", id, err)
+	}
+
+	return nil
+}
+
+func (d *docker) killContainer(ctx context.Context, id, signal string) error {
+	_, err := d.client.ContainerKill(ctx, id, client.ContainerKillOptions{Signal: signal})
+	if err != nil {
+		return fmt.Errorf("can't send %s to container %s: %w", signal, id, err)
+	}
+
+	return nil
+}
+
 func (d *docker) stopClient() error {
 	return d.client.Close()
 }
 
-func (d *docker) removeContainer(ctx context.Context, id string) error {
+// RemoveContainer removes a container. It doesn't fail if the container no
+// longer exists.
+func (d *docker) RemoveContainer(ctx context.Context, id string) error {
 	d.lock.Lock()
 	defer d.lock.Unlock()
 
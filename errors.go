@@ -1,8 +1,61 @@
 package gnomock
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // ErrEnvClient means that Gnomock can't connect to docker daemon in the
 // testing environment. See https://docs.docker.com/compose/reference/overview/
 // for information on required configuration.
 var ErrEnvClient = fmt.Errorf("can't connect to docker host")
+
+// ErrImageNotPresent means that the image doesn't exist locally, and it can't
+// be pulled because of PullNever pull policy.
+var ErrImageNotPresent = fmt.Errorf("image not present locally")
+
+// ErrContainerExited means that the container stopped running before it
+// became ready to use, for example because of invalid configuration.
+var ErrContainerExited = fmt.Errorf("container exited")
+
+// ErrNoDockerHealthcheck means that WithDockerHealthCheck was used without a
+// command, but the image doesn't define a HEALTHCHECK instruction, so docker
+// never reports the container as healthy.
+var ErrNoDockerHealthcheck = fmt.Errorf("docker healthcheck is not defined")
+
+// ContainerExitError means that the container stopped running before it
+// became ready to use. It includes the state reported by the container
+// runtime, and the last lines the container wrote to stdout and stderr, which
+// usually explain the failure. It matches ErrContainerExited when using
+// errors.Is.
+type ContainerExitError struct {
+	// ExitCode is the exit code of the main process of the container, or -1
+	// if the container was removed before the exit code could be read.
+	ExitCode int `json:"exitCode"`
+
+	// OOMKilled is true when the container was killed because it ran out of
+	// memory.
+	OOMKilled bool `json:"oomKilled"`
+
+	// Logs are the last lines of container stdout and stderr.
+	Logs []string `json:"logs,omitempty"`
+}
+
+func (e *ContainerExitError) Error() string {
+	msg := fmt.Sprintf("container exited with code %d", e.ExitCode)
+
+	if e.OOMKilled {
+		msg += " (out of memory)"
+	}
+
+	if len(e.Logs) > 0 {
+		msg += ", last logs:\n" + strings.Join(e.Logs, "\n")
+	}
+
+	return msg
+}
+
+// Is allows to match ContainerExitError using ErrContainerExited.
+func (e *ContainerExitError) Is(target error) bool {
+	return target == ErrContainerExited
+}
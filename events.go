@@ -0,0 +1,103 @@
+package gnomock
+
+import "time"
+
+// EventType identifies a step of container lifecycle.
+type EventType string
+
+// Event types emitted by Gnomock. See WithEventHandler.
+const (
+	// EventImagePullStarted is emitted before the image is pulled.
+	EventImagePullStarted EventType = "image_pull_started"
+
+	// EventImagePullProgress is emitted for every progress update received
+	// from docker while the image is pulled. Message includes the update.
+	EventImagePullProgress EventType = "image_pull_progress"
+
+	// EventImagePullDone is emitted when the image is pulled. Duration is
+	// the time it took to pull the image.
+	EventImagePullDone EventType = "image_pull_done"
+
+	// EventContainerCreated is emitted when the container is created, but
+	// not yet started. Duration is the time it took to create it.
+	EventContainerCreated EventType = "container_created"
+
+	// EventContainerStarted is emitted when the container is started and
+	// its ports are bound. Duration is the time it took to start it.
+	EventContainerStarted EventType = "container_started"
+
+	// EventHealthcheckFailed is emitted every time the healthcheck fails.
+	// Err includes the healthcheck error, and Duration is the time passed
+	// since Gnomock began to wait for the container.
+	EventHealthcheckFailed EventType = "healthcheck_failed"
+
+	// EventHealthy is emitted when the container becomes ready to use.
+	// Duration is the time it took to become healthy.
+	EventHealthy EventType = "healthy"
+
+	// EventInitDone is emitted when InitFunc completes successfully.
+	// Duration is the time it took to run it.
+	EventInitDone EventType = "init_done"
+
+	// EventStopped is emitted when the container is stopped. Duration is the
+	// time it took to stop and remove it.
+	EventStopped EventType = "stopped"
+)
+
+// Event describes a single step of container lifecycle. Use WithEventHandler
+// to receive events, for example to find out which startup step takes the
+// most time.
+type Event struct {
+	// Type of this event.
+	Type EventType
+
+	// Time when this event happened.
+	Time time.Time
+
+	// Image used to create the container.
+	Image string
+
+	// ContainerID is the ID of the container as known to Docker. It is empty
+	// before the container is created.
+	ContainerID string
+
+	// Duration of the step this event completes, if applicable.
+	Duration time.Duration
+
+	// Message includes additional information, such as pull progress.
+	Message string
+
+	// Err is the error that caused this event, if any.
+	Err error
+}
+
+// EventHandler receives container lifecycle events. It is called
+// synchronously, so it should return quickly.
+type EventHandler func(Event)
+
+// Emit sends the provided event to the event handler set using
+// WithEventHandler, if any. Custom runtimes use it to report the steps they
+// perform, such as image pull.
+func (o *Options) Emit(e Event) {
+	if o.eventHandler == nil {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	o.eventHandler(e)
+}
+
+// event creates a new event of the provided type for this container. The
+// duration of the event is the time passed since start.
+func (c *Container) event(t EventType, start time.Time, err error) Event {
+	return Event{
+		Type:        t,
+		Image:       c.image,
+		ContainerID: c.DockerID(),
+		Duration:    time.Since(start),
+		Err:         err,
+	}
+}
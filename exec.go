@@ -0,0 +1,34 @@
+package gnomock
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// Exec runs the provided command with its arguments inside this container,
+// and waits for it to complete. It returns everything the command wrote to
+// stdout and stderr, and its exit code. A non-zero exit code is not
+// considered an error: err is only returned if the command couldn't run.
+//
+// Exec can be used both from InitFunc and after Start returns.
+func (c *Container) Exec(ctx context.Context, cmd []string) (stdout, stderr []byte, exitCode int, err error) {
+	var outBuf, errBuf bytes.Buffer
+
+	exitCode, err = c.ExecStream(ctx, cmd, &outBuf, &errBuf)
+
+	return outBuf.Bytes(), errBuf.Bytes(), exitCode, err
+}
+
+// ExecStream works like Exec, but instead of collecting the output of the
+// command, it writes it into the provided stdout and stderr as soon as it
+// arrives. Use it for long running commands, or when the output is too large
+// to keep in memory.
+func (c *Container) ExecStream(ctx context.Context, cmd []string, stdout, stderr io.Writer) (exitCode int, err error) {
+	err = withDocker(func(cli *docker) error {
+		exitCode, err = cli.exec(ctx, c.DockerID(), cmd, stdout, stderr)
+		return err
+	})
+
+	return exitCode, err
+}
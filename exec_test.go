@@ -0,0 +1,58 @@
+package gnomock_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainer_Exec(t *testing.T) {
+	t.Parallel()
+
+	container, err := gnomock.StartCustom(
+		"docker.io/library/busybox:1.35.0",
+		gnomock.DefaultTCP(testutil.GoodPort80),
+		gnomock.WithCommand("sleep", "60"),
+	)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, gnomock.Stop(container))
+	}()
+
+	ctx := context.Background()
+
+	t.Run("stdout and exit code", func(t *testing.T) {
+		stdout, stderr, code, err := container.Exec(ctx, []string{"echo", "hello"})
+		require.NoError(t, err)
+		require.Equal(t, 0, code)
+		require.Equal(t, "hello\n", string(stdout))
+		require.Empty(t, stderr)
+	})
+
+	t.Run("stderr and non-zero exit code", func(t *testing.T) {
+		_, stderr, code, err := container.Exec(ctx, []string{"sh", "-c", "echo oops >&2; exit 3"})
+		require.NoError(t, err)
+		require.Equal(t, 3, code)
+		require.Equal(t, "oops\n", string(stderr))
+	})
+
+	t.Run("streaming output", func(t *testing.T) {
+		var stdout, stderr strings.Builder
+
+		code, err := container.ExecStream(ctx, []string{"sh", "-c", "echo 1; echo 2"}, &stdout, &stderr)
+		require.NoError(t, err)
+		require.Equal(t, 0, code)
+		require.Equal(t, "1\n2\n", stdout.String())
+	})
+
+	t.Run("unknown container", func(t *testing.T) {
+		c := &gnomock.Container{ID: "invalid"}
+		_, _, _, err := c.Exec(ctx, []string{"true"})
+		require.Error(t, err)
+	})
+}
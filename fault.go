@@ -0,0 +1,80 @@
+package gnomock
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultFaultImage is used to run `tc` in the network namespace of a
+// container, unless another image is set using WithFaultImage.
+const defaultFaultImage = "docker.io/nicolaka/netshoot:v0.13"
+
+// Fault is a network condition injected into a container using InjectFault.
+type Fault struct {
+	netem []string
+}
+
+// Latency delays every packet sent by the container by the provided
+// duration.
+func Latency(d time.Duration) Fault {
+	return Fault{netem: []string{"delay", strconv.FormatInt(d.Microseconds(), 10) + "us"}}
+}
+
+// PacketLoss drops the provided percentage of packets sent by the container,
+// for example 10 for 10%.
+func PacketLoss(percent float64) Fault {
+	return Fault{netem: []string{"loss", strconv.FormatFloat(percent, 'f', -1, 64) + "%"}}
+}
+
+// Blackhole drops all packets sent by the container, so that it looks
+// unreachable to its clients while still running.
+func Blackhole() Fault {
+	return Fault{netem: []string{"loss", "100%"}}
+}
+
+// InjectFault applies the provided network faults to all traffic sent by this
+// container, replacing the faults injected before. Multiple faults can be
+// combined, for example latency with packet loss. Use ClearFaults to restore
+// the network.
+//
+// Faults are applied using `tc` in a short-lived sidecar container that
+// shares the network namespace of this container. It requires NET_ADMIN
+// capability, which docker grants to the sidecar. The image of the sidecar can
+// be replaced using WithFaultImage when the container starts.
+func (c *Container) InjectFault(ctx context.Context, faults ...Fault) error {
+	args := make([]string, 0, len(faults)*2)
+	for _, f := range faults {
+		args = append(args, f.netem...)
+	}
+
+	return c.tc(ctx, `tc qdisc replace dev "$dev" root netem `+strings.Join(args, " "))
+}
+
+// ClearFaults removes all network faults injected into this container using
+// InjectFault.
+func (c *Container) ClearFaults(ctx context.Context) error {
+	// the root qdisc is deleted only where netem was set, since deleting the
+	// default one fails
+	return c.tc(ctx, `! tc qdisc show dev "$dev" root | grep -q netem || tc qdisc del dev "$dev" root`)
+}
+
+// tc runs the provided command for every network interface of this container
+// except loopback. The name of the interface is available as $dev.
+func (c *Container) tc(ctx context.Context, command string) error {
+	script := fmt.Sprintf(
+		`for dev in $(ls /sys/class/net); do [ "$dev" = lo ] || { %s; } || exit 1; done`,
+		command,
+	)
+
+	image := defaultFaultImage
+	if c.config != nil && c.config.faultImage != "" {
+		image = c.config.faultImage
+	}
+
+	return withDocker(func(cli *docker) error {
+		return cli.runNetworkSidecar(ctx, c.DockerID(), image, script)
+	})
+}
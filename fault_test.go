@@ -0,0 +1,59 @@
+package gnomock_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainer_InjectFault(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	container, err := gnomock.Start(&testutil.TestPreset{Img: testutil.TestImage})
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, gnomock.Stop(container)) }()
+
+	t.Run("latency", func(t *testing.T) {
+		require.NoError(t, container.InjectFault(ctx, gnomock.Latency(time.Millisecond*500)))
+
+		start := time.Now()
+		require.NoError(t, testutil.Healthcheck(ctx, container))
+		require.Greater(t, time.Since(start), time.Millisecond*500)
+
+		require.NoError(t, container.ClearFaults(ctx))
+	})
+
+	t.Run("blackhole", func(t *testing.T) {
+		require.NoError(t, container.InjectFault(ctx, gnomock.Blackhole()))
+
+		blackholeCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		require.Error(t, testutil.Healthcheck(blackholeCtx, container))
+
+		require.NoError(t, container.ClearFaults(ctx))
+		require.NoError(t, testutil.Healthcheck(ctx, container))
+	})
+}
+
+func TestContainer_InjectFault_customImage(t *testing.T) {
+	t.Parallel()
+
+	container, err := gnomock.Start(
+		&testutil.TestPreset{Img: testutil.TestImage},
+		gnomock.WithFaultImage("localhost:1/gnomock/missing-fault-image:1"),
+	)
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, gnomock.Stop(container)) }()
+
+	err = container.InjectFault(context.Background(), gnomock.Blackhole())
+	require.ErrorContains(t, err, "missing-fault-image")
+}
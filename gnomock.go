@@ -34,7 +34,6 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/moby/moby/api/pkg/stdcopy"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
@@ -113,36 +112,72 @@ func newContainer(g *g, image string, ports NamedPorts, config *Options) (c *Con
 	ctx, cancel := context.WithTimeout(config.ctx, config.Timeout)
 	defer cancel()
 
-	cli, err := g.dockerConnect()
+	rt, release, err := g.runtime(config)
 	if err != nil {
-		return nil, fmt.Errorf("can't create docker client: %w", err)
+		return nil, err
 	}
 
-	defer func() { _ = cli.stopClient() }()
+	defer release()
 
-	c, err = cli.startContainer(ctx, image, ports, config)
+	c, err = g.startContainer(ctx, rt, image, ports, config)
 	if err != nil {
 		return nil, fmt.Errorf("can't start container: %w", err)
 	}
 
+	c.image, c.config = image, config
+
+	// shared containers that are reused are already set up, and are still
+	// used by other processes, so they are neither initialized again nor
+	// stopped on failure
+	sharedReuse := config.shared && c.reused
+
 	defer func() {
-		if err != nil {
-			if !config.Debug && Stop(c) == nil {
-				c = nil
+		if err == nil {
+			return
+		}
+
+		if sharedReuse {
+			// only the resources of the current process are released
+			if c.onStop != nil {
+				_ = c.onStop()
 			}
+
+			_ = c.closeProxies()
+
+			return
+		}
+
+		if !config.Debug && Stop(c) == nil {
+			c = nil
 		}
 	}()
 
-	err = g.setupLogForwarding(c, cli, config)
+	if config.proxy {
+		err = c.setupProxies()
+		if err != nil {
+			return nil, fmt.Errorf("can't setup proxies: %w", err)
+		}
+	}
+
+	var logs *logMatcher
+	if config.waitForLog != nil {
+		logs = newLogMatcher(config.waitForLog, config.waitForLogOccurrences)
+	}
+
+	err = g.setupLogForwarding(c, rt, config, logs)
 	if err != nil {
 		return nil, fmt.Errorf("can't setup log forwarding: %w", err)
 	}
 
-	err = g.wait(ctx, c, config)
+	err = g.wait(ctx, rt, c, config, logs)
 	if err != nil {
 		return c, fmt.Errorf("can't connect to container: %w", err)
 	}
 
+	if sharedReuse {
+		return c, nil
+	}
+
 	err = g.initf(ctx, c, config)
 	if err != nil {
 		return c, fmt.Errorf("can't init container: %w", err)
@@ -153,8 +188,8 @@ func newContainer(g *g, image string, ports NamedPorts, config *Options) (c *Con
 
 func copyf(dst io.Writer, src io.Reader) func() error {
 	return func() error {
-		_, err := stdcopy.StdCopy(dst, dst, src)
-		if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+		_, err := io.Copy(dst, src)
+		if err != nil && !errors.Is(err, io.ErrClosedPipe) && !errors.Is(err, net.ErrClosed) {
 			return err
 		}
 
@@ -193,7 +228,8 @@ func closeLogReader(logReader io.ReadCloser, g *errgroup.Group) func() error {
 func Start(p Preset, opts ...Option) (*Container, error) {
 	presetOpts := p.Options()
 
-	mergedOpts := make([]Option, 0, len(opts)+len(presetOpts))
+	mergedOpts := make([]Option, 0, len(opts)+len(presetOpts)+1)
+	mergedOpts = append(mergedOpts, withPreset(p))
 	mergedOpts = append(mergedOpts, presetOpts...)
 	mergedOpts = append(mergedOpts, opts...)
 
@@ -224,6 +260,36 @@ func Stop(cs ...*Container) error {
 	return eg.Wait()
 }
 
+// Health checks whether this container is still running and healthy. If the
+// container was started by the current process, the healthcheck used by
+// Start runs again. ErrContainerExited is returned if the container is no
+// longer running.
+func (c *Container) Health(ctx context.Context) error {
+	g, err := newG(isInDocker())
+	if err != nil {
+		return err
+	}
+
+	defer func() { _ = g.log.Sync() }()
+
+	rt, release, err := g.runtime(c.config)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	if err := g.checkContainer(ctx, rt, c, c.config); err != nil {
+		return err
+	}
+
+	if c.config == nil {
+		return nil
+	}
+
+	return c.config.healthcheck(ctx, envAwareClone(c))
+}
+
 func (g *g) stop(c *Container) error {
 	if c == nil {
 		return nil
@@ -231,26 +297,29 @@ func (g *g) stop(c *Container) error {
 
 	g.log.Infow("stopping", "container", c)
 
-	cli, err := g.dockerConnect()
+	start := time.Now()
+
+	rt, release, err := g.runtime(c.config)
 	if err != nil {
-		return fmt.Errorf("can't create docker client: %w", err)
+		return err
 	}
 
-	defer func() { _ = cli.stopClient() }()
+	defer release()
 
 	id, sidecar := parseID(c.ID)
 
-	// Stop the sidecar container (best-effort) before returning, even if
-	// stopping the main container fails. Sidecar stop errors are intentionally
-	// ignored because sidecar containers have a self-destruct timer and will
-	// be cleaned up automatically.
+	// Stop and remove the sidecar container (best-effort) before returning,
+	// even if stopping the main container fails. Sidecar errors are
+	// intentionally ignored because sidecar containers have a self-destruct
+	// timer and remove themselves once they exit.
 	if sidecar != "" {
 		defer func() {
-			_ = cli.stopContainer(context.Background(), sidecar)
+			_ = rt.StopContainer(context.Background(), sidecar)
+			_ = rt.RemoveContainer(context.Background(), sidecar)
 		}()
 	}
 
-	err = cli.stopContainer(context.Background(), id)
+	err = rt.StopContainer(context.Background(), id)
 	if err != nil {
 		return fmt.Errorf("can't stop container: %w", err)
 	}
@@ -262,7 +331,21 @@ func (g *g) stop(c *Container) error {
 		}
 	}
 
-	return cli.removeContainer(context.Background(), id)
+	err = c.closeProxies()
+	if err != nil {
+		return fmt.Errorf("can't close proxies: %w", err)
+	}
+
+	err = rt.RemoveContainer(context.Background(), id)
+	if err != nil {
+		return err
+	}
+
+	if c.config != nil {
+		c.config.Emit(c.event(EventStopped, start, nil))
+	}
+
+	return nil
 }
 
 func buildImage(image string) string {
@@ -275,27 +358,47 @@ func buildImage(image string) string {
 	return image
 }
 
-func (g *g) setupLogForwarding(c *Container, cli *docker, config *Options) error {
-	if config.logWriter == io.Discard {
-		return nil
+// setupLogForwarding streams container logs into the configured log writer
+// and log matcher. The last lines are also kept in the container, so that
+// they can be reported if the container exits during startup.
+func (g *g) setupLogForwarding(c *Container, rt Runtime, config *Options, logs *logMatcher) error {
+	c.logTail = newLogTail(exitLogLines)
+
+	w := io.MultiWriter(config.logWriter, c.logTail)
+	if logs != nil {
+		w = io.MultiWriter(w, logs)
 	}
 
-	logReader, err := cli.readLogs(context.Background(), c.DockerID())
+	logReader, err := rt.ContainerLogs(context.Background(), c.DockerID())
 	if err != nil {
 		return fmt.Errorf("can't create log reader: %w", err)
 	}
 
 	eg := &errgroup.Group{}
-	eg.Go(copyf(config.logWriter, logReader))
+	eg.Go(func() error {
+		defer c.logTail.close()
+
+		return copyf(w, logReader)()
+	})
 	c.onStop = closeLogReader(logReader, eg)
 
 	return nil
 }
 
-func (g *g) wait(ctx context.Context, c *Container, config *Options) error {
+func (g *g) wait(ctx context.Context, rt Runtime, c *Container, config *Options, logs *logMatcher) error {
+	start := time.Now()
+
+	if logs != nil {
+		if err := g.waitForLog(ctx, rt, c, config, logs); err != nil {
+			return err
+		}
+	}
+
 	g.log.Info("waiting for healthcheck to pass")
 
-	delay := time.NewTicker(config.healthcheckInterval)
+	b := newBackoff(config)
+
+	delay := time.NewTimer(b.next())
 	defer delay.Stop()
 
 	var lastErr error
@@ -305,14 +408,49 @@ func (g *g) wait(ctx context.Context, c *Container, config *Options) error {
 		case <-ctx.Done():
 			return fmt.Errorf("canceled after error: %w", lastErr)
 		case <-delay.C:
-			err := config.healthcheck(ctx, envAwareClone(c))
+			err := g.checkContainer(ctx, rt, c, config)
+			if errors.Is(err, ErrContainerExited) || errors.Is(err, ErrNoDockerHealthcheck) {
+				return err
+			}
+
+			if err == nil {
+				err = config.healthcheck(ctx, envAwareClone(c))
+			}
+
 			if err == nil {
 				g.log.Info("container is healthy")
+				config.Emit(c.event(EventHealthy, start, nil))
+
 				return nil
 			}
 
 			g.log.Infof("healthcheck failed: %s", err.Error())
+			config.Emit(c.event(EventHealthcheckFailed, start, err))
 			lastErr = err
+
+			delay.Reset(b.next())
+		}
+	}
+}
+
+func (g *g) waitForLog(ctx context.Context, rt Runtime, c *Container, config *Options, logs *logMatcher) error {
+	g.log.Infow("waiting for log pattern", "pattern", logs.re.String(), "occurrences", logs.want)
+
+	tick := time.NewTicker(config.healthcheckInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("log pattern %q not found: %w", logs.re.String(), ctx.Err())
+		case <-logs.done:
+			g.log.Info("log pattern found")
+			return nil
+		case <-tick.C:
+			err := g.checkContainer(ctx, rt, c, nil)
+			if errors.Is(err, ErrContainerExited) {
+				return err
+			}
 		}
 	}
 }
@@ -320,7 +458,16 @@ func (g *g) wait(ctx context.Context, c *Container, config *Options) error {
 func (g *g) initf(ctx context.Context, c *Container, config *Options) error {
 	g.log.Info("starting initial state setup")
 
-	return config.init(ctx, envAwareClone(c))
+	start := time.Now()
+
+	err := config.init(ctx, envAwareClone(c))
+	if err != nil {
+		return err
+	}
+
+	config.Emit(c.event(EventInitDone, start, nil))
+
+	return nil
 }
 
 // envAwareClone returns a copy of the provided container adjusted for usage
@@ -332,14 +479,17 @@ func (g *g) initf(ctx context.Context, c *Container, config *Options) error {
 // container or from docker host.
 func envAwareClone(c *Container) *Container {
 	containerCopy := &Container{
-		ID:    c.ID,
-		Host:  c.Host,
-		Ports: c.Ports,
+		ID:            c.ID,
+		Host:          c.Host,
+		Ports:         c.Ports,
+		internalHost:  c.internalHost,
+		internalPorts: c.internalPorts,
 	}
 
 	// when gnomock runs inside docker container, the other container is only
-	// accessible through the host
-	if isInDocker() {
+	// accessible through the host, unless it is accessed using proxies that
+	// run in the same process
+	if isInDocker() && len(c.proxies) == 0 {
 		if isHostDockerInternalAvailable() {
 			containerCopy.Host = "host.docker.internal"
 		} else {
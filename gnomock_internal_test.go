@@ -1,12 +1,22 @@
 package gnomock
 
 import (
+	"archive/tar"
 	"context"
+	"encoding/json"
 	"errors"
+	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
+	"github.com/orlangure/gnomock/internal/catalog"
+	"github.com/orlangure/gnomock/internal/envaware"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
 )
@@ -51,18 +61,18 @@ func TestWaitForContainerNetwork(t *testing.T) {
 		ctx, cancel := context.WithCancel(ctx)
 		cancel()
 
-		_, err = d.waitForContainerNetwork(ctx, id, namedPorts)
+		_, err = gg.waitForContainerNetwork(ctx, d, id, namedPorts)
 		require.EqualError(t, err, "container network is unavailable after timeout")
 	})
 
 	t.Run("fails with wrong container id", func(t *testing.T) {
-		_, err = d.waitForContainerNetwork(ctx, "wrong-id", namedPorts)
+		_, err = gg.waitForContainerNetwork(ctx, d, "wrong-id", namedPorts)
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "No such container")
 	})
 
 	t.Run("returns ErrPortNotFound for wrong port number", func(t *testing.T) {
-		_, err := d.waitForContainerNetwork(ctx, id, DefaultTCP(42))
+		_, err := gg.waitForContainerNetwork(ctx, d, id, DefaultTCP(42))
 		require.Error(t, err)
 		require.True(t, errors.Is(err, ErrPortNotFound), err.Error())
 	})
@@ -96,6 +106,7 @@ func TestEnvAwareClone(t *testing.T) {
 		cloned := envAwareClone(original)
 		require.Equal(t, original.ID, cloned.ID)
 		require.Equal(t, original.gateway, cloned.Host)
+		require.Equal(t, cloned, envaware.Clone(original))
 	})
 }
 
@@ -160,3 +171,463 @@ func TestCustomDockerHost(t *testing.T) {
 		})
 	})
 }
+
+func TestLogMatcher(t *testing.T) {
+	t.Parallel()
+
+	t.Run("matches lines split across writes", func(t *testing.T) {
+		m := newLogMatcher(regexp.MustCompile(`ready \d+`), 2)
+
+		_, _ = m.Write([]byte("starting\nrea"))
+		_, _ = m.Write([]byte("dy 1\nready"))
+
+		select {
+		case <-m.done:
+			t.Fatal("matched too early")
+		default:
+		}
+
+		_, _ = m.Write([]byte(" 2\nready 3\n"))
+
+		select {
+		case <-m.done:
+		default:
+			t.Fatal("pattern not matched")
+		}
+
+		require.Equal(t, 2, m.count)
+	})
+
+	t.Run("at least one occurrence is expected", func(t *testing.T) {
+		m := newLogMatcher(regexp.MustCompile(`ready`), 0)
+		n, err := m.Write([]byte("ready\n"))
+		require.NoError(t, err)
+		require.Equal(t, 6, n)
+
+		<-m.done
+	})
+}
+
+func TestArchive(t *testing.T) {
+	t.Parallel()
+
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "sub"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "sub", "b.txt"), []byte("b"), 0o600))
+
+	archive, err := filesArchive(
+		map[string][]byte{"/etc/app/a.txt": []byte("a")},
+		map[string]string{"/data": src},
+	)
+	require.NoError(t, err)
+
+	var names []string
+
+	tr := tar.NewReader(archive)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		require.NoError(t, err)
+
+		names = append(names, hdr.Name)
+	}
+
+	require.Equal(t, []string{"etc/app/a.txt", "data/", "data/sub/", "data/sub/b.txt"}, names)
+
+	t.Run("extract archive with top level directory", func(t *testing.T) {
+		archive, err := filesArchive(nil, map[string]string{"/data": src})
+		require.NoError(t, err)
+
+		dst := filepath.Join(t.TempDir(), "out")
+		require.NoError(t, extractArchive(archive, dst))
+
+		content, err := os.ReadFile(filepath.Join(dst, "sub", "b.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "b", string(content))
+	})
+
+	t.Run("extract archive with a single file", func(t *testing.T) {
+		archive, err := filesArchive(map[string][]byte{"/a.txt": []byte("a")}, nil)
+		require.NoError(t, err)
+
+		dst := filepath.Join(t.TempDir(), "renamed.txt")
+		require.NoError(t, extractArchive(archive, dst))
+
+		content, err := os.ReadFile(dst)
+		require.NoError(t, err)
+		require.Equal(t, "a", string(content))
+	})
+
+	t.Run("missing host file", func(t *testing.T) {
+		_, err := filesArchive(nil, map[string]string{"/data": filepath.Join(src, "missing")})
+		require.Error(t, err)
+	})
+}
+
+func TestReadPullProgress(t *testing.T) {
+	t.Parallel()
+
+	output := `{"status":"Pulling from library/busybox","id":"1.35.0"}
+{"status":"Downloading","progressDetail":{"current":1,"total":2},"progress":"[=>  ]","id":"abc"}
+{"status":"Status: Downloaded newer image for busybox:1.35.0"}
+`
+
+	var updates []string
+
+	err := readPullProgress(strings.NewReader(output), func(msg string) {
+		updates = append(updates, msg)
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"1.35.0 Pulling from library/busybox",
+		"abc Downloading [=>  ]",
+		"Status: Downloaded newer image for busybox:1.35.0",
+	}, updates)
+
+	require.Error(t, readPullProgress(strings.NewReader("{"), func(string) {}))
+}
+
+func TestOptionsPlatform(t *testing.T) {
+	t.Parallel()
+
+	p, err := buildConfig().platform()
+	require.NoError(t, err)
+	require.Nil(t, p)
+
+	p, err = buildConfig(WithPlatform("linux/arm64/v8")).platform()
+	require.NoError(t, err)
+	require.Equal(t, "linux", p.OS)
+	require.Equal(t, "arm64", p.Architecture)
+	require.Equal(t, "v8", p.Variant)
+
+	for _, invalid := range []string{"linux", "linux/", "/arm64", "linux/arm64/v8/extra"} {
+		_, err = buildConfig(WithPlatform(invalid)).platform()
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestOptionsPullPolicy(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, PullAlways, buildConfig().pullPolicy())
+	require.Equal(t, PullIfNotPresent, buildConfig(WithUseLocalImagesFirst()).pullPolicy())
+	require.Equal(t, PullNever, buildConfig(WithUseLocalImagesFirst(), WithPullPolicy(PullNever)).pullPolicy())
+}
+
+func TestResources(t *testing.T) {
+	t.Parallel()
+
+	d := &docker{}
+	cfg := buildConfig(
+		WithMemoryLimit(512*1024*1024),
+		WithCPUs(1.5),
+		WithUlimits(Ulimit{Name: "nofile", Soft: 1024, Hard: 2048}),
+	)
+
+	resources := d.resources(cfg)
+	require.Equal(t, int64(512*1024*1024), resources.Memory)
+	require.Equal(t, int64(1500000000), resources.NanoCPUs)
+	require.Len(t, resources.Ulimits, 1)
+	require.Equal(t, "nofile", resources.Ulimits[0].Name)
+	require.Equal(t, int64(1024), resources.Ulimits[0].Soft)
+	require.Equal(t, int64(2048), resources.Ulimits[0].Hard)
+
+	t.Run("options from json", func(t *testing.T) {
+		var options Options
+
+		err := json.Unmarshal([]byte(`{
+			"memory_limit": 1024,
+			"cpus": 2,
+			"shm_size": 2048,
+			"ulimits": [{"name": "nproc", "soft": 10, "hard": 20}],
+			"tmpfs": {"/data": "rw"}
+		}`), &options)
+		require.NoError(t, err)
+
+		cfg := buildConfig(WithOptions(&options))
+		require.Equal(t, int64(1024), cfg.MemoryLimit)
+		require.Equal(t, float64(2), cfg.CPUs)
+		require.Equal(t, int64(2048), cfg.ShmSize)
+		require.Equal(t, []Ulimit{{Name: "nproc", Soft: 10, Hard: 20}}, cfg.Ulimits)
+		require.Equal(t, map[string]string{"/data": "rw"}, cfg.Tmpfs)
+	})
+}
+
+func TestLabels(t *testing.T) {
+	t.Parallel()
+
+	labels := buildConfig(
+		WithLabels(map[string]string{"foo": "bar", LabelSession: "ignored"}),
+		withPreset(&testPreset{}),
+	).labels()
+
+	require.Equal(t, "bar", labels["foo"])
+	require.Equal(t, session, labels[LabelSession])
+	require.Equal(t, "gnomock.testPreset", labels[LabelPreset])
+	require.NotEmpty(t, labels[LabelBinary])
+	require.NotEmpty(t, labels[LabelCreated])
+	require.NotContains(t, labels, LabelReuse)
+
+	labels = buildConfig(WithContainerReuse()).labels()
+	require.Equal(t, "true", labels[LabelReuse])
+	require.NotContains(t, labels, LabelPreset)
+}
+
+func TestSharedState(t *testing.T) {
+	t.Parallel()
+
+	t.Run("name depends on image, preset configuration and options", func(t *testing.T) {
+		config := buildConfig(WithEnv("FOO=bar"))
+
+		name, err := sharedName(&testPreset{Version: "1"}, "foo:1", config)
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(name, sharedPrefix), name)
+
+		same, err := sharedName(&testPreset{Version: "1"}, "foo:1", buildConfig(WithEnv("FOO=bar")))
+		require.NoError(t, err)
+		require.Equal(t, name, same)
+
+		otherVersion, err := sharedName(&testPreset{Version: "2"}, "foo:1", config)
+		require.NoError(t, err)
+		require.NotEqual(t, name, otherVersion)
+
+		otherImage, err := sharedName(&testPreset{Version: "1"}, "foo:2", config)
+		require.NoError(t, err)
+		require.NotEqual(t, name, otherImage)
+
+		otherOptions, err := sharedName(&testPreset{Version: "1"}, "foo:1", buildConfig(WithEnv("FOO=baz")))
+		require.NoError(t, err)
+		require.NotEqual(t, name, otherOptions)
+	})
+
+	t.Run("counts users across acquire and release", func(t *testing.T) {
+		name := sharedPrefix + uuid.NewString()
+		t.Cleanup(func() { _ = os.Remove(sharedPath(name)) })
+
+		require.NoError(t, acquireShared(name, "foo"))
+		require.NoError(t, acquireShared(name, "foo"))
+
+		last, err := releaseShared(name)
+		require.NoError(t, err)
+		require.False(t, last)
+
+		last, err = releaseShared(name)
+		require.NoError(t, err)
+		require.True(t, last)
+		require.NoFileExists(t, sharedPath(name))
+	})
+
+	t.Run("forgets users of replaced container", func(t *testing.T) {
+		name := sharedPrefix + uuid.NewString()
+		t.Cleanup(func() { _ = os.Remove(sharedPath(name)) })
+
+		require.NoError(t, acquireShared(name, "foo"))
+		require.NoError(t, acquireShared(name, "bar"))
+
+		last, err := releaseShared(name)
+		require.NoError(t, err)
+		require.True(t, last)
+	})
+
+	t.Run("forgets users that exited", func(t *testing.T) {
+		name := sharedPrefix + uuid.NewString()
+		t.Cleanup(func() { _ = os.Remove(sharedPath(name)) })
+
+		cmd := exec.Command(os.Args[0], "-test.run=^$")
+		require.NoError(t, cmd.Run())
+		require.False(t, processRunning(cmd.Process.Pid))
+
+		exited := &sharedState{ContainerID: "foo", Users: []int{cmd.Process.Pid}}
+		require.NoError(t, writeSharedState(name, exited))
+
+		require.NoError(t, acquireShared(name, "foo"))
+
+		last, err := releaseShared(name)
+		require.NoError(t, err)
+		require.True(t, last)
+	})
+
+	t.Run("lock is exclusive", func(t *testing.T) {
+		name := sharedPrefix + uuid.NewString()
+
+		unlock, err := lockShared(context.Background(), name)
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+		defer cancel()
+
+		_, err = lockShared(ctx, name)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		unlock()
+
+		unlock, err = lockShared(context.Background(), name)
+		require.NoError(t, err)
+
+		unlock()
+	})
+}
+
+func TestEnvFile(t *testing.T) {
+	t.Parallel()
+
+	catalog.Add("gnomock-test", func() any { return &testPreset{} })
+
+	env, err := parseEnvFile([]byte(`
+networks: [backend]
+containers:
+  app:
+    image: docker.io/example/app
+    ports:
+      web: {protocol: tcp, port: 8080}
+    depends_on: [db, cache]
+    options:
+      cmd: [serve]
+      networks:
+        backend: [app]
+        external: []
+  cache:
+    preset: gnomock-test
+  db:
+    preset: gnomock-test
+    config:
+      version: "2"
+    depends_on: [cache]
+`))
+	require.NoError(t, err)
+	require.Equal(t, []string{"backend"}, env.Networks)
+
+	order, err := env.order()
+	require.NoError(t, err)
+	require.Equal(t, []string{"cache", "db", "app"}, order)
+
+	t.Run("presets", func(t *testing.T) {
+		p, err := env.Containers["db"].preset("db")
+		require.NoError(t, err)
+		require.Equal(t, &envPreset{Preset: &testPreset{Version: "2"}, name: "db"}, p)
+		require.Equal(t, "gnomock.testPreset", presetName(p))
+
+		p, err = env.Containers["app"].preset("app")
+		require.NoError(t, err)
+		require.Equal(t, "docker.io/example/app:latest", p.Image())
+		require.Equal(t, NamedPorts{"web": TCP(8080)}, p.Ports())
+		require.Empty(t, presetName(p))
+
+		_, err = (&envContainer{Preset: "unknown"}).preset("foo")
+		require.EqualError(t, err, "preset unknown of container foo not found")
+	})
+
+	t.Run("options", func(t *testing.T) {
+		networks := map[string]*Network{"backend": {Name: "gnomock-backend"}}
+		cfg := buildConfig(env.Containers["app"].options(context.Background(), networks)...)
+
+		require.Equal(t, map[string][]string{"gnomock-backend": {"app"}, "external": nil}, cfg.Networks)
+		require.Equal(t, []string{"serve"}, cfg.Cmd)
+	})
+
+	t.Run("dependency env", func(t *testing.T) {
+		dep := &envPreset{Preset: &testPreset{}, name: "db"}
+		require.Equal(t, "db", dependencyPrefix(dep))
+
+		c := &Container{Host: "127.0.0.1", Ports: DefaultTCP(45432)}
+		require.Empty(t, buildConfig(dependencyEnv("db", c)...).Env)
+
+		c.internalHost, c.internalPorts = "db", DefaultTCP(5432)
+		require.Equal(t, []string{"DB_DEFAULT_ADDR=db:5432"}, buildConfig(dependencyEnv("db", c)...).Env)
+
+		b := InParallel().Start(&testPreset{}).Start(&testPreset{})
+		deps := []*Container{
+			{internalHost: "first", internalPorts: DefaultTCP(80), Ports: DefaultTCP(80)},
+			{internalHost: "second", internalPorts: DefaultTCP(80), Ports: DefaultTCP(80)},
+		}
+		cfg := buildConfig(b.options(configuredPreset{deps: []int{0, 1}}, deps)...)
+
+		require.Equal(t, []string{"GNOMOCK_DEFAULT_ADDR=first:80", "GNOMOCK_2_DEFAULT_ADDR=second:80"}, cfg.Env)
+	})
+
+	t.Run("invalid files", func(t *testing.T) {
+		for name, file := range map[string]string{
+			"unknown dependency":    "containers: {app: {image: app, depends_on: [db]}}",
+			"circular dependency":   "containers: {a: {image: a, depends_on: [b]}, b: {image: b, depends_on: [a]}}",
+			"no preset or image":    "containers: {app: {}}",
+			"both preset and image": "containers: {app: {image: app, preset: redis}}",
+			"unknown field":         "containers: {app: {image: app, foo: bar}}",
+		} {
+			env, err := parseEnvFile([]byte(file))
+			if err == nil {
+				_, err = env.order()
+			}
+
+			require.Error(t, err, name)
+		}
+	})
+}
+
+func TestFaults(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, []string{"delay", "200000us"}, Latency(time.Millisecond*200).netem)
+	require.Equal(t, []string{"loss", "12.5%"}, PacketLoss(12.5).netem)
+	require.Equal(t, []string{"loss", "100%"}, Blackhole().netem)
+}
+
+func TestLogTail(t *testing.T) {
+	t.Parallel()
+
+	tail := newLogTail(3)
+
+	_, _ = tail.Write([]byte("one\ntwo\nthr"))
+	require.Equal(t, []string{"one", "two", "thr"}, tail.lines())
+
+	_, _ = tail.Write([]byte("ee\nfour\nfive\n"))
+	require.Equal(t, []string{"three", "four", "five"}, tail.lines())
+
+	_, _ = tail.Write([]byte("six"))
+	require.Equal(t, []string{"four", "five", "six"}, tail.lines())
+
+	tail.close()
+	tail.close()
+	<-tail.done
+}
+
+func TestBackoff(t *testing.T) {
+	t.Parallel()
+
+	t.Run("constant interval by default", func(t *testing.T) {
+		b := newBackoff(buildConfig(WithHealthCheckInterval(time.Second)))
+
+		for range 3 {
+			require.Equal(t, time.Second, b.next())
+		}
+	})
+
+	t.Run("exponential", func(t *testing.T) {
+		b := newBackoff(buildConfig(WithHealthCheckBackoff(time.Second, time.Second*5, 0)))
+
+		for _, want := range []int{1, 2, 4, 5, 5} {
+			require.Equal(t, time.Second*time.Duration(want), b.next())
+		}
+	})
+
+	t.Run("jitter", func(t *testing.T) {
+		b := newBackoff(buildConfig(WithHealthCheckBackoff(time.Second, time.Second, 0.1)))
+
+		for range 10 {
+			d := b.next()
+			require.GreaterOrEqual(t, d, time.Millisecond*900)
+			require.LessOrEqual(t, d, time.Millisecond*1100)
+		}
+	})
+}
+
+type testPreset struct {
+	Version string `json:"version"`
+}
+
+func (p *testPreset) Image() string     { return testImage }
+func (p *testPreset) Ports() NamedPorts { return DefaultTCP(80) }
+func (p *testPreset) Options() []Option { return nil }
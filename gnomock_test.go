@@ -6,6 +6,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -106,6 +109,47 @@ func TestGnomock_customHealthcheck(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestGnomock_containerExit(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+
+	container, err := gnomock.StartCustom(
+		"docker.io/library/busybox:1.35.0",
+		gnomock.DefaultTCP(testutil.GoodPort80),
+		gnomock.WithCommand("sh", "-c", "sleep 1; echo invalid license; exit 3"),
+	)
+	require.ErrorIs(t, err, gnomock.ErrContainerExited)
+	require.Nil(t, container)
+	require.Less(t, time.Since(start), time.Minute)
+
+	var exitErr *gnomock.ContainerExitError
+
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, 3, exitErr.ExitCode)
+	require.False(t, exitErr.OOMKilled)
+	require.Contains(t, exitErr.Logs, "invalid license")
+}
+
+func TestGnomock_withDockerHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	container, err := gnomock.StartCustom(
+		"docker.io/library/busybox:1.35.0",
+		gnomock.DefaultTCP(testutil.GoodPort80),
+		gnomock.WithCommand("sh", "-c", "sleep 1; touch /tmp/ready; sleep 60"),
+		gnomock.WithDockerHealthCheck("test", "-f", "/tmp/ready"),
+		gnomock.WithHealthCheckBackoff(time.Millisecond*100, time.Second, 0.1),
+		gnomock.WithTimeout(time.Minute),
+	)
+	require.NoError(t, err)
+
+	_, _, code, err := container.Exec(context.Background(), []string{"test", "-f", "/tmp/ready"})
+	require.NoError(t, err)
+	require.Equal(t, 0, code)
+	require.NoError(t, gnomock.Stop(container))
+}
+
 func TestGnomock_initError(t *testing.T) {
 	t.Parallel()
 
@@ -152,6 +196,14 @@ func TestGnomock_withDebugMode(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, containerList, 0)
 
+	// the cleaner sidecar is removed together with the container
+	_, sidecar, ok := strings.Cut(container.ID, "-")
+	require.True(t, ok, container.ID)
+
+	containerList, err = testutil.ListContainerByID(cli, sidecar)
+	require.NoError(t, err)
+	require.Len(t, containerList, 0)
+
 	container, err = gnomock.StartCustom(
 		testutil.TestImage, gnomock.DefaultTCP(testutil.GoodPort80),
 		gnomock.WithDebugMode(),
@@ -198,6 +250,78 @@ func TestGnomock_withLogWriter(t *testing.T) {
 	require.NoError(t, r.Close())
 }
 
+func TestGnomock_withWaitForLog(t *testing.T) {
+	t.Parallel()
+
+	container, err := gnomock.StartCustom(
+		testutil.TestImage, gnomock.DefaultTCP(testutil.GoodPort80),
+		gnomock.WithWaitForLog(regexp.MustCompile(`starting with env1 = 'foo'`), 1),
+		gnomock.WithEnv("GNOMOCK_TEST_1=foo"),
+		gnomock.WithTimeout(time.Minute),
+	)
+	require.NoError(t, err)
+	require.NoError(t, gnomock.Stop(container))
+
+	container, err = gnomock.StartCustom(
+		testutil.TestImage, gnomock.DefaultTCP(testutil.GoodPort80),
+		gnomock.WithWaitForLog(regexp.MustCompile(`this line is never logged`), 1),
+		gnomock.WithTimeout(time.Second*5),
+	)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, context.DeadlineExceeded), err.Error())
+	require.Nil(t, container)
+}
+
+func TestGnomock_withEventHandler(t *testing.T) {
+	t.Parallel()
+
+	var (
+		lock   sync.Mutex
+		events []gnomock.EventType
+	)
+
+	handler := func(e gnomock.Event) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if e.Type != gnomock.EventImagePullProgress {
+			events = append(events, e.Type)
+		}
+	}
+
+	healthcheckCalls := 0
+	healthcheck := func(context.Context, *gnomock.Container) error {
+		healthcheckCalls++
+		if healthcheckCalls == 1 {
+			return fmt.Errorf("not ready yet")
+		}
+
+		return nil
+	}
+
+	container, err := gnomock.StartCustom(
+		testutil.TestImage, gnomock.DefaultTCP(testutil.GoodPort80),
+		gnomock.WithEventHandler(handler),
+		gnomock.WithHealthCheck(healthcheck),
+	)
+	require.NoError(t, err)
+	require.NoError(t, gnomock.Stop(container))
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	require.Equal(t, []gnomock.EventType{
+		gnomock.EventImagePullStarted,
+		gnomock.EventImagePullDone,
+		gnomock.EventContainerCreated,
+		gnomock.EventContainerStarted,
+		gnomock.EventHealthcheckFailed,
+		gnomock.EventHealthy,
+		gnomock.EventInitDone,
+		gnomock.EventStopped,
+	}, events)
+}
+
 func TestGnomock_withCommand(t *testing.T) {
 	t.Parallel()
 
@@ -328,6 +452,108 @@ func TestGnomock_witUseLocalImagesFirst(t *testing.T) {
 	})
 }
 
+func TestGnomock_withPullPolicy(t *testing.T) {
+	t.Parallel()
+
+	t.Run("never fails for missing image", func(t *testing.T) {
+		container, err := gnomock.StartCustom(
+			"docker.io/orlangure/noimage",
+			gnomock.DefaultTCP(testutil.GoodPort80),
+			gnomock.WithPullPolicy(gnomock.PullNever),
+		)
+		require.True(t, errors.Is(err, gnomock.ErrImageNotPresent), err)
+		require.Nil(t, container)
+	})
+
+	t.Run("progress is reported when pulling", func(t *testing.T) {
+		progress := &strings.Builder{}
+
+		container, err := gnomock.StartCustom(
+			testutil.TestImage, gnomock.DefaultTCP(testutil.GoodPort80),
+			gnomock.WithPullPolicy(gnomock.PullAlways),
+			gnomock.WithPullProgressWriter(progress),
+			gnomock.WithPlatform("linux/amd64"),
+		)
+		require.NoError(t, err)
+		require.NoError(t, gnomock.Stop(container))
+		require.Contains(t, progress.String(), "Status:")
+
+		container, err = gnomock.StartCustom(
+			testutil.TestImage, gnomock.DefaultTCP(testutil.GoodPort80),
+			gnomock.WithPullPolicy(gnomock.PullNever),
+		)
+		require.NoError(t, err)
+		require.NoError(t, gnomock.Stop(container))
+	})
+}
+
+func TestGnomock_withResourceLimits(t *testing.T) {
+	t.Parallel()
+
+	container, err := gnomock.StartCustom(
+		"docker.io/library/busybox:1.35.0",
+		gnomock.DefaultTCP(testutil.GoodPort80),
+		gnomock.WithCommand("sleep", "60"),
+		gnomock.WithMemoryLimit(64*1024*1024),
+		gnomock.WithCPUs(0.5),
+		gnomock.WithShmSize(32*1024*1024),
+		gnomock.WithUlimits(gnomock.Ulimit{Name: "nofile", Soft: 1024, Hard: 1024}),
+		gnomock.WithTmpfs("/data", "rw,size=16m"),
+	)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, gnomock.Stop(container))
+	}()
+
+	stdout, _, code, err := container.Exec(context.Background(), []string{"sh", "-c", "ulimit -n"})
+	require.NoError(t, err)
+	require.Equal(t, 0, code)
+	require.Equal(t, "1024\n", string(stdout))
+
+	stdout, _, code, err = container.Exec(context.Background(), []string{"sh", "-c", "mount | grep /data"})
+	require.NoError(t, err)
+	require.Equal(t, 0, code)
+	require.Contains(t, string(stdout), "tmpfs")
+}
+
+func TestGnomock_withLabels(t *testing.T) {
+	t.Parallel()
+
+	cli, err := client.New(client.FromEnv)
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, cli.Close()) }()
+
+	p := &testutil.TestPreset{Img: testutil.TestImage}
+	container, err := gnomock.Start(p, gnomock.WithLabels(map[string]string{"foo": "bar"}))
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, gnomock.Stop(container))
+	}()
+
+	inspectResult, err := cli.ContainerInspect(
+		context.Background(), container.DockerID(), client.ContainerInspectOptions{},
+	)
+	require.NoError(t, err)
+
+	labels := inspectResult.Container.Config.Labels
+	require.Equal(t, "bar", labels["foo"])
+	require.Equal(t, "testutil.TestPreset", labels[gnomock.LabelPreset])
+	require.NotEmpty(t, labels[gnomock.LabelSession])
+	require.NotEmpty(t, labels[gnomock.LabelCreated])
+	require.NotEmpty(t, labels[gnomock.LabelBinary])
+
+	t.Run("sweep keeps containers of current session", func(t *testing.T) {
+		require.NoError(t, gnomock.Sweep(context.Background(), 0))
+
+		containerList, err := testutil.ListContainerByID(cli, container.DockerID())
+		require.NoError(t, err)
+		require.Len(t, containerList, 1)
+	})
+}
+
 func TestGnomock_withExtraHosts(t *testing.T) {
 	t.Parallel()
 
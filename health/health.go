@@ -0,0 +1,255 @@
+// Package health includes composable health checks for containers started
+// with Gnomock, for example using gnomock.StartCustom. Every check is a
+// gnomock.HealthcheckFunc, and can be used with gnomock.WithHealthCheck
+// directly, or combined with other checks using All and Any:
+//
+//	gnomock.WithHealthCheck(health.All(
+//		health.TCP("db"),
+//		health.HTTP("api", "/health", health.ExpectStatus(http.StatusOK)),
+//	))
+package health
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/orlangure/gnomock"
+)
+
+// TCP returns a health check that succeeds when a TCP connection to the port
+// with the provided name can be established.
+func TCP(port string) gnomock.HealthcheckFunc {
+	return func(ctx context.Context, c *gnomock.Container) error {
+		var d net.Dialer
+
+		conn, err := d.DialContext(ctx, "tcp", c.Address(port))
+		if err != nil {
+			return fmt.Errorf("can't connect to %s: %w", port, err)
+		}
+
+		return conn.Close()
+	}
+}
+
+// HTTPOption changes the expectations of HTTP health check.
+type HTTPOption func(*httpCheck)
+
+// ExpectStatus makes HTTP health check succeed only when the response status
+// is one of the provided codes. By default, any status below 400 (Bad Request)
+// is accepted.
+func ExpectStatus(codes ...int) HTTPOption {
+	return func(h *httpCheck) {
+		h.codes = codes
+	}
+}
+
+// ExpectBody makes HTTP health check succeed only when the provided function
+// returns true for the response body.
+func ExpectBody(match func(body []byte) bool) HTTPOption {
+	return func(h *httpCheck) {
+		h.match = match
+	}
+}
+
+// ExpectBodyContains makes HTTP health check succeed only when the response
+// body contains the provided string.
+func ExpectBodyContains(s string) HTTPOption {
+	return ExpectBody(func(body []byte) bool {
+		return bytes.Contains(body, []byte(s))
+	})
+}
+
+type httpCheck struct {
+	codes []int
+	match func([]byte) bool
+}
+
+// HTTP returns a health check that sends a GET request to the provided path
+// on the port with the provided name, and succeeds when the response meets
+// the expectations set using options.
+func HTTP(port, path string, opts ...HTTPOption) gnomock.HealthcheckFunc {
+	h := &httpCheck{}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+
+	return func(ctx context.Context, c *gnomock.Container) error {
+		url := fmt.Sprintf("http://%s%s", c.Address(port), path)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			_ = res.Body.Close()
+		}()
+
+		if !h.acceptStatus(res.StatusCode) {
+			return fmt.Errorf("unexpected response code: %d", res.StatusCode)
+		}
+
+		if h.match == nil {
+			return nil
+		}
+
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			return fmt.Errorf("can't read response body: %w", err)
+		}
+
+		if !h.match(body) {
+			return fmt.Errorf("unexpected response body: %s", body)
+		}
+
+		return nil
+	}
+}
+
+func (h *httpCheck) acceptStatus(code int) bool {
+	if len(h.codes) == 0 {
+		return code < http.StatusBadRequest
+	}
+
+	for _, c := range h.codes {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TLS returns a health check that succeeds when TLS handshake with the port
+// with the provided name completes. If cfg is nil, server certificate is not
+// verified, which allows to check containers with self-signed certificates.
+func TLS(port string, cfg *tls.Config) gnomock.HealthcheckFunc {
+	if cfg == nil {
+		cfg = &tls.Config{InsecureSkipVerify: true} // nolint:gosec
+	}
+
+	return func(ctx context.Context, c *gnomock.Container) error {
+		d := tls.Dialer{Config: cfg}
+
+		conn, err := d.DialContext(ctx, "tcp", c.Address(port))
+		if err != nil {
+			return fmt.Errorf("can't complete tls handshake with %s: %w", port, err)
+		}
+
+		return conn.Close()
+	}
+}
+
+// SQL returns a health check that connects to a database using the provided
+// driver, and succeeds when the database responds to a ping. The driver must
+// be registered by the caller, usually with a blank import. dsn function
+// receives the container and returns the data source name to connect to.
+func SQL(driver string, dsn func(*gnomock.Container) string) gnomock.HealthcheckFunc {
+	return func(ctx context.Context, c *gnomock.Container) error {
+		db, err := sql.Open(driver, dsn(c))
+		if err != nil {
+			return fmt.Errorf("can't open database: %w", err)
+		}
+
+		defer func() { _ = db.Close() }()
+
+		if err := db.PingContext(ctx); err != nil {
+			return fmt.Errorf("can't ping database: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// Exec returns a health check that runs the provided command inside the
+// container, and succeeds when it exits with code 0.
+func Exec(cmd ...string) gnomock.HealthcheckFunc {
+	return func(ctx context.Context, c *gnomock.Container) error {
+		stdout, stderr, code, err := c.Exec(ctx, cmd)
+		if err != nil {
+			return err
+		}
+
+		if code != 0 {
+			return fmt.Errorf(
+				"command exited with code %d: %s",
+				code, bytes.TrimSpace(append(stdout, stderr...)),
+			)
+		}
+
+		return nil
+	}
+}
+
+// Log returns a health check that succeeds when any line the container wrote
+// to stdout or stderr matches the provided pattern.
+func Log(re *regexp.Regexp) gnomock.HealthcheckFunc {
+	return func(ctx context.Context, c *gnomock.Container) error {
+		logs, err := c.Logs(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, line := range bytes.Split(logs, []byte("\n")) {
+			if re.Match(line) {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("no log lines match %s", re)
+	}
+}
+
+// All returns a health check that succeeds when all the provided checks
+// succeed. The checks run in the provided order, and the first error is
+// returned.
+func All(checks ...gnomock.HealthcheckFunc) gnomock.HealthcheckFunc {
+	return func(ctx context.Context, c *gnomock.Container) error {
+		for _, check := range checks {
+			if err := check(ctx, c); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// Any returns a health check that succeeds when at least one of the provided
+// checks succeeds. The checks run in the provided order until one of them
+// succeeds. If all of them fail, all the errors are returned.
+func Any(checks ...gnomock.HealthcheckFunc) gnomock.HealthcheckFunc {
+	return func(ctx context.Context, c *gnomock.Container) error {
+		errs := make([]error, 0, len(checks))
+
+		for _, check := range checks {
+			err := check(ctx, c)
+			if err == nil {
+				return nil
+			}
+
+			errs = append(errs, err)
+		}
+
+		return errors.Join(errs...)
+	}
+}
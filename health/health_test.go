@@ -0,0 +1,192 @@
+package health_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/health"
+	"github.com/orlangure/gnomock/internal/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+)
+
+func TestMain(m *testing.M) {
+	goleak.VerifyTestMain(m)
+}
+
+var (
+	errUnhealthy = errors.New("unhealthy")
+
+	healthy   = func(context.Context, *gnomock.Container) error { return nil }
+	unhealthy = func(context.Context, *gnomock.Container) error { return errUnhealthy }
+)
+
+func TestTCP(t *testing.T) {
+	ctx := context.Background()
+
+	l, err := (&net.ListenConfig{}).Listen(ctx, "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	c := containerAt(t, l.Addr().String())
+
+	require.NoError(t, health.TCP(gnomock.DefaultPort)(ctx, c))
+	require.NoError(t, l.Close())
+	require.Error(t, health.TCP(gnomock.DefaultPort)(ctx, c))
+}
+
+func TestHTTP(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("status: ok"))
+	}))
+	defer s.Close()
+
+	c := containerAt(t, s.Listener.Addr().String())
+
+	t.Run("default status", func(t *testing.T) {
+		require.NoError(t, health.HTTP(gnomock.DefaultPort, "health")(ctx, c))
+
+		err := health.HTTP(gnomock.DefaultPort, "/foo")(ctx, c)
+		require.EqualError(t, err, "unexpected response code: 404")
+	})
+
+	t.Run("expected status", func(t *testing.T) {
+		check := health.HTTP(gnomock.DefaultPort, "/health", health.ExpectStatus(http.StatusAccepted))
+		require.NoError(t, check(ctx, c))
+
+		check = health.HTTP(gnomock.DefaultPort, "/health", health.ExpectStatus(http.StatusOK))
+		require.EqualError(t, check(ctx, c), "unexpected response code: 202")
+	})
+
+	t.Run("expected body", func(t *testing.T) {
+		check := health.HTTP(gnomock.DefaultPort, "/health", health.ExpectBodyContains("ok"))
+		require.NoError(t, check(ctx, c))
+
+		check = health.HTTP(gnomock.DefaultPort, "/health", health.ExpectBodyContains("ready"))
+		require.EqualError(t, check(ctx, c), "unexpected response body: status: ok")
+	})
+}
+
+func TestTLS(t *testing.T) {
+	ctx := context.Background()
+
+	s := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer s.Close()
+
+	c := containerAt(t, s.Listener.Addr().String())
+
+	require.NoError(t, health.TLS(gnomock.DefaultPort, nil)(ctx, c))
+
+	// client configuration of the test server trusts its certificate
+	err := health.TLS(gnomock.DefaultPort, s.Client().Transport.(*http.Transport).TLSClientConfig)(ctx, c)
+	require.NoError(t, err)
+
+	plain := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer plain.Close()
+
+	require.Error(t, health.TLS(gnomock.DefaultPort, nil)(ctx, containerAt(t, plain.Listener.Addr().String())))
+}
+
+func TestSQL(t *testing.T) {
+	ctx := context.Background()
+	c := containerAt(t, "127.0.0.1:5432")
+
+	check := health.SQL("gnomock-test", func(c *gnomock.Container) string {
+		return c.DefaultAddress()
+	})
+	require.NoError(t, check(ctx, c))
+
+	check = health.SQL("gnomock-test", func(*gnomock.Container) string { return "down" })
+	require.Error(t, check(ctx, c))
+
+	check = health.SQL("unknown", func(*gnomock.Container) string { return "" })
+	require.Error(t, check(ctx, c))
+}
+
+func TestAll(t *testing.T) {
+	ctx := context.Background()
+
+	require.NoError(t, health.All()(ctx, nil))
+	require.NoError(t, health.All(healthy, healthy)(ctx, nil))
+	require.ErrorIs(t, health.All(healthy, unhealthy)(ctx, nil), errUnhealthy)
+}
+
+func TestAny(t *testing.T) {
+	ctx := context.Background()
+
+	require.NoError(t, health.Any()(ctx, nil))
+	require.NoError(t, health.Any(unhealthy, healthy)(ctx, nil))
+	require.ErrorIs(t, health.Any(unhealthy, unhealthy)(ctx, nil), errUnhealthy)
+}
+
+func containerAt(t *testing.T, addr string) *gnomock.Container {
+	t.Helper()
+
+	host, portStr, err := net.SplitHostPort(addr)
+	require.NoError(t, err)
+
+	port, err := strconv.Atoi(portStr)
+	require.NoError(t, err)
+
+	return &gnomock.Container{Host: host, Ports: gnomock.DefaultTCP(port)}
+}
+
+func init() {
+	sql.Register("gnomock-test", testDriver{})
+}
+
+// testDriver accepts any data source name except "down", which fails to
+// connect, and is used to test SQL health check without a real database.
+type testDriver struct{}
+
+func (testDriver) Open(name string) (driver.Conn, error) {
+	if name == "down" {
+		return nil, errUnhealthy
+	}
+
+	return testConn{}, nil
+}
+
+type testConn struct{}
+
+func (testConn) Prepare(string) (driver.Stmt, error) { return nil, errors.ErrUnsupported }
+func (testConn) Close() error                        { return nil }
+func (testConn) Begin() (driver.Tx, error)           { return nil, errors.ErrUnsupported }
+
+func TestContainerChecks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	c, err := gnomock.StartCustom(
+		testutil.TestImage, gnomock.DefaultTCP(testutil.GoodPort80),
+		gnomock.WithEnv("GNOMOCK_TEST_1=foo"),
+		gnomock.WithHealthCheck(health.All(
+			health.TCP(gnomock.DefaultPort),
+			health.Log(regexp.MustCompile(`starting with env1 = 'foo'`)),
+		)),
+	)
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, gnomock.Stop(c)) }()
+
+	require.NoError(t, health.Exec("true")(ctx, c))
+	require.EqualError(t, health.Exec("sh", "-c", "echo oops; exit 3")(ctx, c), "command exited with code 3: oops")
+	require.Error(t, health.Log(regexp.MustCompile(`this line is never logged`))(ctx, c))
+}
@@ -0,0 +1,29 @@
+// Package catalog keeps preset factories registered using registry package.
+// Unlike registry, it doesn't depend on gnomock package, so gnomock itself can
+// use it to find presets by name.
+package catalog
+
+import "sync"
+
+var (
+	mu        sync.RWMutex
+	factories = map[string]func() any{}
+)
+
+// Add saves the provided preset factory under the provided name.
+func Add(name string, f func() any) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	factories[name] = f
+}
+
+// Get returns a preset factory saved under the provided name.
+func Get(name string) (func() any, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	f, ok := factories[name]
+
+	return f, ok
+}
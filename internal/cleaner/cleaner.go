@@ -9,10 +9,13 @@ import (
 	"net/http"
 )
 
-// Image and Port to create Cleaner containers.
+// Image and Port to create Cleaner containers. Version is the last released
+// version of the image, so that it always refers to a published image. It is
+// updated in the release commit, together with the other version references.
 const (
-	Image = "docker.io/orlangure/gnomock-cleaner"
-	Port  = 8008
+	Version = "1.26.0"
+	Image   = "docker.io/orlangure/gnomock-cleaner:" + Version
+	Port    = 8008
 )
 
 // Notify sends a new request to the cleaner process running at the provided
@@ -29,3 +32,9 @@ func Notify(ctx context.Context, addr, id string) error {
 
 	return nil
 }
+
+// NotifyNetwork works like Notify, but the cleaner removes the network with
+// the provided id instead of a container.
+func NotifyNetwork(ctx context.Context, addr, id string) error {
+	return Notify(ctx, addr, "network/"+id)
+}
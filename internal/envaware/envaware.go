@@ -0,0 +1,10 @@
+// Package envaware gives internal packages access to the container addresses
+// gnomock uses to reach containers from the current environment, for example
+// when gnomock runs inside a container as gnomockd. It doesn't depend on
+// gnomock package, which sets the implementation, so these addresses don't
+// become a part of gnomock API.
+package envaware
+
+// Clone returns a copy of the provided *gnomock.Container with an address
+// reachable from the current environment. Other values are returned as is.
+var Clone = func(c any) any { return c }
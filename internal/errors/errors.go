@@ -48,17 +48,22 @@ func (e invalidStartRequestError) Error() string {
 // NewStartFailedError means that the container failed to start for some
 // reason.
 func NewStartFailedError(err error, c *gnomock.Container) error {
-	return startFailedError{
+	e := startFailedError{
 		err:       err,
 		ErrStr:    fmt.Sprintf("start failed: %v", err),
 		Container: c,
 	}
+
+	_ = errors.As(err, &e.Exit)
+
+	return e
 }
 
 type startFailedError struct {
 	err       error
-	ErrStr    string             `json:"error"`
-	Container *gnomock.Container `json:"container,omitempty"`
+	ErrStr    string                      `json:"error"`
+	Container *gnomock.Container          `json:"container,omitempty"`
+	Exit      *gnomock.ContainerExitError `json:"exit,omitempty"`
 }
 
 func (e startFailedError) Error() string {
@@ -102,12 +107,104 @@ func (e stopFailedError) Error() string {
 	return e.ErrStr
 }
 
+// InvalidContainerRequestError means that the request parameters of a call
+// that changes the state of an existing container were invalid.
+func InvalidContainerRequestError(err error) error {
+	return invalidContainerRequestError{
+		err:    err,
+		ErrStr: fmt.Sprintf("invalid container request: %v", err),
+	}
+}
+
+type invalidContainerRequestError struct {
+	err    error
+	ErrStr string `json:"error"`
+}
+
+func (e invalidContainerRequestError) Error() string {
+	return e.ErrStr
+}
+
+// ContainerActionFailedError means that the requested action, such as pause
+// or restart, failed on the container.
+func ContainerActionFailedError(action string, err error, c *gnomock.Container) error {
+	return containerActionFailedError{
+		err:       err,
+		ErrStr:    fmt.Sprintf("%s failed: %v", action, err),
+		Container: c,
+	}
+}
+
+type containerActionFailedError struct {
+	err       error
+	ErrStr    string             `json:"error"`
+	Container *gnomock.Container `json:"container,omitempty"`
+}
+
+func (e containerActionFailedError) Error() string {
+	return e.ErrStr
+}
+
+// ContainerNotFoundError means that the requested container was not started
+// by this server, or it was already stopped.
+func ContainerNotFoundError(id string) error {
+	return containerNotFoundError{
+		id:     id,
+		ErrStr: fmt.Sprintf("container '%s' not found", id),
+	}
+}
+
+type containerNotFoundError struct {
+	id     string
+	ErrStr string `json:"error"`
+}
+
+func (e containerNotFoundError) Error() string {
+	return e.ErrStr
+}
+
+// JobNotFoundError means that the requested start job doesn't exist, or that
+// it was already deleted.
+func JobNotFoundError(id string) error {
+	return jobNotFoundError{
+		id:     id,
+		ErrStr: fmt.Sprintf("job '%s' not found", id),
+	}
+}
+
+type jobNotFoundError struct {
+	id     string
+	ErrStr string `json:"error"`
+}
+
+func (e jobNotFoundError) Error() string {
+	return e.ErrStr
+}
+
+// ActionNotFoundError means that the requested preset doesn't support the
+// requested action.
+func ActionNotFoundError(preset, action string) error {
+	return actionNotFoundError{
+		ErrStr: fmt.Sprintf("action '%s' of preset '%s' not found", action, preset),
+	}
+}
+
+type actionNotFoundError struct {
+	ErrStr string `json:"error"`
+}
+
+func (e actionNotFoundError) Error() string {
+	return e.ErrStr
+}
+
 // ErrorCode returns HTTP response code for the provided error.
 func ErrorCode(err error) int {
 	switch {
-	case errors.As(err, &invalidStartRequestError{}), errors.As(err, &invalidStopRequestError{}):
+	case errors.As(err, &invalidStartRequestError{}), errors.As(err, &invalidStopRequestError{}),
+		errors.As(err, &invalidContainerRequestError{}):
 		return http.StatusBadRequest
-	case errors.As(err, &presetNotFoundError{}):
+	case errors.As(err, &presetNotFoundError{}), errors.As(err, &containerNotFoundError{}),
+		errors.As(err, &jobNotFoundError{}), errors.As(err, &actionNotFoundError{}):
 		return http.StatusNotFound
 	default:
 		return http.StatusInternalServerError
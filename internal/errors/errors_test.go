@@ -1,6 +1,7 @@
 package errors_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"testing"
@@ -37,6 +38,38 @@ func TestStartFailedError(t *testing.T) {
 	require.Equal(t, http.StatusInternalServerError, errors.ErrorCode(err))
 }
 
+func TestStartFailedError_containerExit(t *testing.T) {
+	exitErr := &gnomock.ContainerExitError{ExitCode: 3, OOMKilled: true, Logs: []string{"bad license"}}
+	err := errors.NewStartFailedError(fmt.Errorf("can't connect to container: %w", exitErr), nil)
+	require.Equal(t, http.StatusInternalServerError, errors.ErrorCode(err))
+
+	bs, err := json.Marshal(err)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"error": "start failed: can't connect to container: `+
+		`container exited with code 3 (out of memory), last logs:\nbad license",
+		"exit": {"exitCode": 3, "oomKilled": true, "logs": ["bad license"]}
+	}`, string(bs))
+}
+
+func TestContainerNotFoundError(t *testing.T) {
+	err := errors.ContainerNotFoundError("foobar")
+	require.Equal(t, "container 'foobar' not found", err.Error())
+	require.Equal(t, http.StatusNotFound, errors.ErrorCode(err))
+}
+
+func TestJobNotFoundError(t *testing.T) {
+	err := errors.JobNotFoundError("foobar")
+	require.Equal(t, "job 'foobar' not found", err.Error())
+	require.Equal(t, http.StatusNotFound, errors.ErrorCode(err))
+}
+
+func TestActionNotFoundError(t *testing.T) {
+	err := errors.ActionNotFoundError("postgres", "foobar")
+	require.Equal(t, "action 'foobar' of preset 'postgres' not found", err.Error())
+	require.Equal(t, http.StatusNotFound, errors.ErrorCode(err))
+}
+
 func TestInvalidStopRequestError(t *testing.T) {
 	rootErr := fmt.Errorf("bad input")
 	err := errors.InvalidStopRequestError(rootErr)
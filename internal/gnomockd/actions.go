@@ -0,0 +1,122 @@
+package gnomockd
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/internal/envaware"
+	"github.com/orlangure/gnomock/internal/errors"
+	"github.com/orlangure/gnomock/internal/registry"
+)
+
+// actionTimeout limits the time a command or a preset action can run on a
+// container.
+const actionTimeout = time.Minute * 5
+
+type execRequest struct {
+	Cmd []string `json:"cmd"`
+}
+
+type execResponse struct {
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	ExitCode int    `json:"exitCode"`
+}
+
+// execHandler runs a command in a tracked container, and responds with its
+// output and exit code. A non-zero exit code is not considered an error.
+func execHandler(cs *containers) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		tc, ok := cs.get(id)
+		if !ok {
+			respondWithError(w, errors.ContainerNotFoundError(id))
+			return
+		}
+
+		var er execRequest
+
+		if err := json.NewDecoder(r.Body).Decode(&er); err != nil {
+			respondWithError(w, errors.InvalidContainerRequestError(err))
+			return
+		}
+
+		if len(er.Cmd) == 0 {
+			respondWithError(w, errors.InvalidContainerRequestError(fmt.Errorf("missing command")))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), actionTimeout)
+		defer cancel()
+
+		stdout, stderr, code, err := tc.Exec(ctx, er.Cmd)
+		if err != nil {
+			respondWithError(w, errors.ContainerActionFailedError("exec", err, tc.Container))
+			return
+		}
+
+		respondWithJSON(w, execResponse{Stdout: string(stdout), Stderr: string(stderr), ExitCode: code})
+	}
+}
+
+// actionHandler runs a preset specific action, for example adding more data,
+// on a tracked container started with that preset. Actions connect to the
+// container from gnomockd itself, so they receive its reachable copy.
+func actionHandler(cs *containers) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		id, preset, name := vars["id"], vars["preset"], vars["action"]
+
+		tc, ok := cs.get(id)
+		if !ok {
+			respondWithError(w, errors.ContainerNotFoundError(id))
+			return
+		}
+
+		action := registry.FindAction(preset, name)
+		if action == nil {
+			respondWithError(w, errors.ActionNotFoundError(preset, name))
+			return
+		}
+
+		if tc.Preset != preset {
+			err := fmt.Errorf("container %s was not started with %s preset", id, preset)
+			respondWithError(w, errors.InvalidContainerRequestError(err))
+
+			return
+		}
+
+		bs, err := io.ReadAll(r.Body)
+		if err != nil {
+			respondWithError(w, errors.InvalidContainerRequestError(err))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), actionTimeout)
+		defer cancel()
+
+		c, ok := envaware.Clone(tc.Container).(*gnomock.Container)
+		if !ok {
+			c = tc.Container
+		}
+
+		err = action(ctx, c, tc.Config, bs)
+
+		switch {
+		case stderrors.Is(err, registry.ErrInvalidActionRequest):
+			respondWithError(w, errors.InvalidContainerRequestError(err))
+		case err != nil:
+			respondWithError(w, errors.ContainerActionFailedError(preset+" "+name, err, tc.Container))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}
+}
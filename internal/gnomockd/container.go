@@ -0,0 +1,78 @@
+package gnomockd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/internal/errors"
+)
+
+// containerAction changes the state of an existing container.
+type containerAction func(ctx context.Context, c *gnomock.Container, cr containerRequest) error
+
+func pause(ctx context.Context, c *gnomock.Container, _ containerRequest) error {
+	return c.Pause(ctx)
+}
+
+func unpause(ctx context.Context, c *gnomock.Container, _ containerRequest) error {
+	return c.Unpause(ctx)
+}
+
+func restart(ctx context.Context, c *gnomock.Container, _ containerRequest) error {
+	return c.Restart(ctx)
+}
+
+func kill(ctx context.Context, c *gnomock.Container, cr containerRequest) error {
+	return c.Kill(ctx, cr.Signal)
+}
+
+// containerHandler runs the provided action on a container, and responds
+// with the container, including its addresses after the action. Containers
+// started by this server are changed using a copy of the original container,
+// so that presets healthchecks still run on restart, and their tracked copy
+// is replaced once the action completes.
+func containerHandler(cs *containers, name string, action containerAction) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var cr containerRequest
+
+		err := json.NewDecoder(r.Body).Decode(&cr)
+		if err != nil {
+			respondWithError(w, errors.InvalidContainerRequestError(err))
+			return
+		}
+
+		if cr.ID == "" {
+			respondWithError(w, errors.InvalidContainerRequestError(fmt.Errorf("missing container id")))
+			return
+		}
+
+		c := &gnomock.Container{ID: cr.ID}
+
+		tc, tracked := cs.get(cr.ID)
+		if tracked {
+			updated := *tc.Container
+			c = &updated
+		}
+
+		err = action(r.Context(), c, cr)
+
+		if tracked {
+			cs.update(c)
+		}
+
+		if err != nil {
+			respondWithError(w, errors.ContainerActionFailedError(name, err, c))
+			return
+		}
+
+		respondWithJSON(w, c)
+	}
+}
+
+type containerRequest struct {
+	ID     string `json:"id"`
+	Signal string `json:"signal"`
+}
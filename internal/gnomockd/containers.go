@@ -0,0 +1,198 @@
+package gnomockd
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/internal/errors"
+)
+
+// healthcheckTimeout limits the time spent checking container health when
+// the container is inspected.
+const healthcheckTimeout = time.Second * 10
+
+// trackedContainer is a container started by this server, along with the
+// request that was used to start it. Containers started from custom images
+// have Image set instead of Preset.
+type trackedContainer struct {
+	*gnomock.Container
+
+	Preset    string          `json:"preset,omitempty"`
+	Image     string          `json:"image,omitempty"`
+	Config    gnomock.Preset  `json:"config,omitempty"`
+	Options   gnomock.Options `json:"options"`
+	StartedAt time.Time       `json:"startedAt"`
+	Uptime    time.Duration   `json:"uptime"`
+
+	// health is only reported when a single container is inspected
+	Health      string `json:"health,omitempty"`
+	HealthError string `json:"healthError,omitempty"`
+}
+
+// containers keeps track of the containers started by this server that are
+// not stopped yet.
+type containers struct {
+	mu   sync.Mutex
+	byID map[string]*trackedContainer
+}
+
+func newContainers() *containers {
+	return &containers{byID: make(map[string]*trackedContainer)}
+}
+
+// add starts tracking the provided container. Registry credentials are
+// removed from its options, so that they are never reported to the clients.
+func (cs *containers) add(tc *trackedContainer) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	tc.Options.Auth = ""
+
+	cs.byID[tc.ID] = tc
+}
+
+func (cs *containers) remove(id string) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	delete(cs.byID, id)
+}
+
+// update replaces the tracked container with the same ID with the provided
+// one, if it is still tracked.
+func (cs *containers) update(c *gnomock.Container) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	if tc, ok := cs.byID[c.ID]; ok {
+		tc.Container = c
+	}
+}
+
+// get returns a copy of the tracked container with the provided ID, with its
+// uptime set.
+func (cs *containers) get(id string) (trackedContainer, bool) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	tc, ok := cs.byID[id]
+	if !ok {
+		return trackedContainer{}, false
+	}
+
+	c := *tc
+	c.Uptime = time.Since(c.StartedAt)
+
+	return c, true
+}
+
+// list returns copies of all the tracked containers, starting from the
+// oldest one.
+func (cs *containers) list() []trackedContainer {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+
+	list := make([]trackedContainer, 0, len(cs.byID))
+
+	for _, tc := range cs.byID {
+		c := *tc
+		c.Uptime = time.Since(c.StartedAt)
+		list = append(list, c)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].StartedAt.Before(list[j].StartedAt)
+	})
+
+	return list
+}
+
+// stopAll stops all the tracked containers, and returns the IDs of the
+// containers that were stopped. Containers that failed to stop remain
+// tracked.
+func (cs *containers) stopAll() ([]string, error) {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		stopped = make([]string, 0)
+		errs    []error
+	)
+
+	for _, tc := range cs.list() {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := gnomock.Stop(tc.Container)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", tc.ID, err))
+				return
+			}
+
+			cs.remove(tc.ID)
+			stopped = append(stopped, tc.ID)
+		}()
+	}
+
+	wg.Wait()
+	sort.Strings(stopped)
+
+	return stopped, stderrors.Join(errs...)
+}
+
+func listHandler(cs *containers) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		respondWithJSON(w, cs.list())
+	}
+}
+
+func inspectHandler(cs *containers) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		tc, ok := cs.get(id)
+		if !ok {
+			respondWithError(w, errors.ContainerNotFoundError(id))
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), healthcheckTimeout)
+		defer cancel()
+
+		tc.Health = "healthy"
+
+		if err := tc.Container.Health(ctx); err != nil {
+			tc.Health, tc.HealthError = "unhealthy", err.Error()
+		}
+
+		respondWithJSON(w, tc)
+	}
+}
+
+func stopAllHandler(cs *containers) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		stopped, err := cs.stopAll()
+		if err != nil {
+			respondWithError(w, errors.StopFailedError(err, nil))
+			return
+		}
+
+		respondWithJSON(w, stopAllResponse{Stopped: stopped})
+	}
+}
+
+type stopAllResponse struct {
+	Stopped []string `json:"stopped"`
+}
@@ -2,6 +2,7 @@
 package gnomockd
 
 import (
+	"context"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -10,13 +11,66 @@ import (
 	"github.com/orlangure/gnomock/internal/errors"
 )
 
+// Server serves gnomockd API, and keeps track of the containers it started,
+// so that they can be listed and stopped together.
+type Server struct {
+	router       *mux.Router
+	containers   *containers
+	jobs         *jobs
+	closeStreams context.CancelFunc
+}
+
+// New creates a new Server with no tracked containers.
+func New() *Server {
+	cs, js := newContainers(), newJobs()
+	streams, closeStreams := context.WithCancel(context.Background())
+
+	router := mux.NewRouter()
+	router.HandleFunc("/start/{name}", startHandler(cs, js)).Methods(http.MethodPost)
+	router.HandleFunc("/start-custom", startCustomHandler(cs, js)).Methods(http.MethodPost)
+	router.HandleFunc("/stop", stopHandler(cs)).Methods(http.MethodPost)
+	router.HandleFunc("/stop-all", stopAllHandler(cs)).Methods(http.MethodPost)
+	router.HandleFunc("/containers", listHandler(cs)).Methods(http.MethodGet)
+	router.HandleFunc("/containers/{id}", inspectHandler(cs)).Methods(http.MethodGet)
+	router.HandleFunc("/containers/{id}/logs", logsHandler(cs, streams)).Methods(http.MethodGet)
+	router.HandleFunc("/containers/{id}/exec", execHandler(cs)).Methods(http.MethodPost)
+	router.HandleFunc("/containers/{id}/{preset}/{action}", actionHandler(cs)).Methods(http.MethodPost)
+	router.HandleFunc("/jobs/{id}", jobHandler(js)).Methods(http.MethodGet)
+	router.HandleFunc("/jobs/{id}", deleteJobHandler(js)).Methods(http.MethodDelete)
+	router.HandleFunc("/pause", containerHandler(cs, "pause", pause)).Methods(http.MethodPost)
+	router.HandleFunc("/unpause", containerHandler(cs, "unpause", unpause)).Methods(http.MethodPost)
+	router.HandleFunc("/restart", containerHandler(cs, "restart", restart)).Methods(http.MethodPost)
+	router.HandleFunc("/kill", containerHandler(cs, "kill", kill)).Methods(http.MethodPost)
+
+	return &Server{router: router, containers: cs, jobs: js, closeStreams: closeStreams}
+}
+
 // Handler returns an HTTP handler ready to serve incoming connections.
+// Containers started using this handler are not stopped automatically; use
+// New and Server.StopAll to stop them when the server shuts down.
 func Handler() http.Handler {
-	router := mux.NewRouter()
-	router.HandleFunc("/start/{name}", startHandler()).Methods(http.MethodPost)
-	router.HandleFunc("/stop", stopHandler()).Methods(http.MethodPost)
+	return New()
+}
 
-	return router
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.router.ServeHTTP(w, r)
+}
+
+// StopAll cancels all the running start jobs, and stops all the containers
+// started by this server that are still running.
+func (s *Server) StopAll() error {
+	s.jobs.cancelAll()
+
+	_, err := s.containers.stopAll()
+	return err
+}
+
+// CloseStreams ends all the active log streams. Following log streams never
+// end on their own while the containers are running, so use CloseStreams
+// with http.Server.RegisterOnShutdown to avoid waiting for them on shutdown.
+func (s *Server) CloseStreams() {
+	s.closeStreams()
 }
 
 func respondWithError(w http.ResponseWriter, err error) {
@@ -27,3 +81,10 @@ func respondWithError(w http.ResponseWriter, err error) {
 		log.Println("can't respond with error:", err)
 	}
 }
+
+func respondWithJSON(w http.ResponseWriter, v any) {
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Println("can't respond:", err)
+	}
+}
@@ -2,11 +2,13 @@ package gnomockd_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/orlangure/gnomock"
 	"github.com/orlangure/gnomock/internal/gnomockd"
@@ -87,6 +89,471 @@ func TestGnomockd(t *testing.T) {
 		require.Equal(t, http.StatusOK, res.StatusCode)
 	})
 
+	for _, action := range []string{"pause", "unpause", "restart", "kill"} {
+		t.Run(action+" with empty body", func(t *testing.T) {
+			t.Parallel()
+
+			h := gnomockd.Handler()
+			w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/"+action, nil)
+			h.ServeHTTP(w, r)
+
+			res := w.Result()
+
+			defer func() { require.NoError(t, res.Body.Close()) }()
+
+			require.Equal(t, http.StatusBadRequest, res.StatusCode)
+		})
+
+		t.Run(action+" with no ID", func(t *testing.T) {
+			t.Parallel()
+
+			h := gnomockd.Handler()
+			buf := bytes.NewBufferString(`{"signal":"SIGHUP"}`)
+			w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/"+action, buf)
+			h.ServeHTTP(w, r)
+
+			res := w.Result()
+
+			defer func() { require.NoError(t, res.Body.Close()) }()
+
+			require.Equal(t, http.StatusBadRequest, res.StatusCode)
+		})
+	}
+
+	invalidCustomRequests := map[string]string{
+		"empty body":          ``,
+		"missing image":       `{"ports":{"default":{"protocol":"tcp","port":80}}}`,
+		"unknown healthcheck": `{"image":"nginx","healthcheck":{"type":"grpc"}}`,
+		"unknown port":        `{"image":"nginx","healthcheck":{"type":"tcp","port":"web"}}`,
+		"invalid pattern":     `{"image":"nginx","healthcheck":{"type":"log","pattern":"("}}`,
+	}
+
+	for name, body := range invalidCustomRequests {
+		t.Run("start custom with "+name, func(t *testing.T) {
+			t.Parallel()
+
+			h := gnomockd.Handler()
+			buf := bytes.NewBufferString(body)
+			w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/start-custom", buf)
+			h.ServeHTTP(w, r)
+
+			res := w.Result()
+
+			defer func() { require.NoError(t, res.Body.Close()) }()
+
+			require.Equal(t, http.StatusBadRequest, res.StatusCode)
+		})
+	}
+
+	t.Run("start custom", func(t *testing.T) {
+		t.Parallel()
+
+		h := gnomockd.Handler()
+		buf := bytes.NewBufferString(`{
+			"image": "docker.io/orlangure/gnomock-test-image",
+			"ports": {
+				"web80": {"protocol": "tcp", "port": 80},
+				"web8080": {"protocol": "tcp", "port": 8080}
+			},
+			"env": ["GNOMOCK_TEST_1=foo"],
+			"healthcheck": {"type": "log", "pattern": "starting with env1 = 'foo'"},
+			"options": {"timeout": 60000000000}
+		}`)
+		w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/start-custom", buf)
+		h.ServeHTTP(w, r)
+
+		res := w.Result()
+
+		t.Cleanup(func() { require.NoError(t, res.Body.Close()) })
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var c gnomock.Container
+
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&c))
+
+		resp, err := http.Get("http://" + c.Address("web80") + "/")
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		w, r = httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/containers/"+c.ID, nil)
+		h.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), `"image":"docker.io/orlangure/gnomock-test-image"`)
+
+		w, r = httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/stop-all", nil)
+		h.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("restart tracked container", func(t *testing.T) {
+		t.Parallel()
+
+		h := gnomockd.Handler()
+		w := post(h, "/start-custom", `{
+			"image": "docker.io/orlangure/gnomock-test-image",
+			"ports": {"web80": {"protocol": "tcp", "port": 80}},
+			"healthcheck": {"type": "http", "port": "web80", "path": "/"},
+			"options": {"timeout": 60000000000}
+		}`)
+		require.Equalf(t, http.StatusOK, w.Code, w.Body.String())
+
+		var c gnomock.Container
+
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&c))
+
+		t.Cleanup(func() { require.Equal(t, http.StatusOK, post(h, "/stop-all", "").Code) })
+
+		w = post(h, "/restart", `{"id":"`+c.ID+`"}`)
+		require.Equalf(t, http.StatusOK, w.Code, w.Body.String())
+
+		var restarted gnomock.Container
+
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&restarted))
+		require.Equal(t, c.ID, restarted.ID)
+
+		// the preset healthcheck passed, so the container is ready
+		resp, err := http.Get("http://" + restarted.Address("web80") + "/")
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/containers/"+c.ID, nil)
+		h.ServeHTTP(w, r)
+
+		var inspected gnomock.Container
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&inspected))
+		require.Equal(t, restarted.Ports, inspected.Ports)
+	})
+
+	t.Run("list with no containers", func(t *testing.T) {
+		t.Parallel()
+
+		h := gnomockd.Handler()
+		w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/containers", nil)
+		h.ServeHTTP(w, r)
+
+		res := w.Result()
+
+		defer func() { require.NoError(t, res.Body.Close()) }()
+
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `[]`, string(body))
+	})
+
+	t.Run("inspect unknown container", func(t *testing.T) {
+		t.Parallel()
+
+		h := gnomockd.Handler()
+		w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/containers/invalid", nil)
+		h.ServeHTTP(w, r)
+
+		res := w.Result()
+
+		defer func() { require.NoError(t, res.Body.Close()) }()
+
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("logs of unknown container", func(t *testing.T) {
+		t.Parallel()
+
+		h := gnomockd.Handler()
+		w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/containers/invalid/logs", nil)
+		h.ServeHTTP(w, r)
+
+		res := w.Result()
+
+		defer func() { require.NoError(t, res.Body.Close()) }()
+
+		require.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("stream logs", func(t *testing.T) {
+		t.Parallel()
+
+		h := gnomockd.Handler()
+		buf := bytes.NewBufferString(`{
+			"image": "docker.io/orlangure/gnomock-test-image",
+			"env": ["GNOMOCK_TEST_1=foo"],
+			"healthcheck": {"type": "log", "pattern": "starting with env1 = 'foo'"},
+			"options": {"timeout": 60000000000}
+		}`)
+		w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/start-custom", buf)
+		h.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var c gnomock.Container
+
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&c))
+
+		t.Cleanup(func() {
+			w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/stop-all", nil)
+			h.ServeHTTP(w, r)
+			require.Equal(t, http.StatusOK, w.Code)
+		})
+
+		w, r = httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/containers/"+c.ID+"/logs", nil)
+		h.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+		require.Contains(t, w.Body.String(), "starting with env1 = 'foo'\n")
+
+		w, r = httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/containers/"+c.ID+"/logs", nil)
+		r.Header.Set("Accept", "text/event-stream")
+		h.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+		require.Regexp(t, `event: std(out|err)\ndata: starting with env1 = 'foo'\n\n`, w.Body.String())
+
+		since := time.Now().Add(time.Hour).Format(time.RFC3339)
+		w, r = httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/containers/"+c.ID+"/logs?since="+since, nil)
+		h.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Empty(t, w.Body.String())
+
+		w, r = httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/containers/"+c.ID+"/logs?since=yesterday", nil)
+		h.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		w = httptest.NewRecorder()
+		r = httptest.NewRequestWithContext(ctx, http.MethodGet, "/containers/"+c.ID+"/logs?follow=true", nil)
+		h.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Contains(t, w.Body.String(), "starting with env1 = 'foo'\n")
+	})
+
+	for _, path := range []string{"/containers/invalid/exec", "/containers/invalid/postgres/queries"} {
+		t.Run("post "+path, func(t *testing.T) {
+			t.Parallel()
+
+			h := gnomockd.Handler()
+			buf := bytes.NewBufferString(`{"cmd":["true"]}`)
+			w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, buf)
+			h.ServeHTTP(w, r)
+
+			res := w.Result()
+
+			defer func() { require.NoError(t, res.Body.Close()) }()
+
+			require.Equal(t, http.StatusNotFound, res.StatusCode)
+		})
+	}
+
+	t.Run("stop all with no containers", func(t *testing.T) {
+		t.Parallel()
+
+		h := gnomockd.Handler()
+		w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/stop-all", nil)
+		h.ServeHTTP(w, r)
+
+		res := w.Result()
+
+		defer func() { require.NoError(t, res.Body.Close()) }()
+
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"stopped":[]}`, string(body))
+	})
+
+	t.Run("list, inspect and stop all", func(t *testing.T) {
+		t.Parallel()
+
+		h := gnomockd.Handler()
+		buf := bytes.NewBufferString(`{"options":{"timeout":60000000000}}`)
+		w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/start/mongo", buf)
+		h.ServeHTTP(w, r)
+
+		res := w.Result()
+
+		t.Cleanup(func() { require.NoError(t, res.Body.Close()) })
+		require.Equal(t, http.StatusOK, res.StatusCode)
+
+		var c gnomock.Container
+
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&c))
+
+		w, r = httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/containers", nil)
+		h.ServeHTTP(w, r)
+
+		var list []struct {
+			ID     string `json:"id"`
+			Preset string `json:"preset"`
+		}
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&list))
+		require.Len(t, list, 1)
+		require.Equal(t, c.ID, list[0].ID)
+		require.Equal(t, "mongo", list[0].Preset)
+
+		w, r = httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/containers/"+c.ID, nil)
+		h.ServeHTTP(w, r)
+
+		var inspected struct {
+			Ports  gnomock.NamedPorts `json:"ports"`
+			Uptime int64              `json:"uptime"`
+			Health string             `json:"health"`
+		}
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&inspected))
+		require.Equal(t, c.Ports, inspected.Ports)
+		require.Positive(t, inspected.Uptime)
+		require.Equal(t, "healthy", inspected.Health)
+
+		w, r = httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/stop-all", nil)
+		h.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"stopped":["`+c.ID+`"]}`, w.Body.String())
+
+		w, r = httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/containers/"+c.ID, nil)
+		h.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("start with invalid async parameter", func(t *testing.T) {
+		t.Parallel()
+
+		h := gnomockd.Handler()
+		buf := bytes.NewBufferString(`{"image":"docker.io/orlangure/gnomock-test-image"}`)
+		w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/start-custom?async=maybe", buf)
+		h.ServeHTTP(w, r)
+
+		res := w.Result()
+
+		defer func() { require.NoError(t, res.Body.Close()) }()
+
+		require.Equal(t, http.StatusBadRequest, res.StatusCode)
+	})
+
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		t.Run(method+" unknown job", func(t *testing.T) {
+			t.Parallel()
+
+			h := gnomockd.Handler()
+			w, r := httptest.NewRecorder(), httptest.NewRequest(method, "/jobs/invalid", nil)
+			h.ServeHTTP(w, r)
+
+			res := w.Result()
+
+			defer func() { require.NoError(t, res.Body.Close()) }()
+
+			require.Equal(t, http.StatusNotFound, res.StatusCode)
+		})
+	}
+
+	t.Run("start async", func(t *testing.T) {
+		t.Parallel()
+
+		h := gnomockd.Handler()
+		buf := bytes.NewBufferString(`{"options":{"timeout":60000000000}}`)
+		w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/start/mongo?async=true", buf)
+		h.ServeHTTP(w, r)
+
+		type job struct {
+			ID        string             `json:"id"`
+			Phase     string             `json:"phase"`
+			Container *gnomock.Container `json:"container"`
+			Error     string             `json:"error"`
+		}
+
+		var j job
+
+		require.Equal(t, http.StatusAccepted, w.Code)
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&j))
+		require.NotEmpty(t, j.ID)
+		require.Nil(t, j.Container)
+
+		require.Eventually(t, func() bool {
+			w, r = httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/jobs/"+j.ID, nil)
+			h.ServeHTTP(w, r)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&j))
+			require.NotEqual(t, "failed", j.Phase, j.Error)
+
+			return j.Phase == "ready"
+		}, time.Minute, time.Millisecond*250)
+
+		require.NotNil(t, j.Container)
+
+		w, r = httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/containers/"+j.Container.ID, nil)
+		h.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w, r = httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/jobs/"+j.ID, nil)
+		h.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w, r = httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/jobs/"+j.ID, nil)
+		h.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusNotFound, w.Code)
+
+		w, r = httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/stop-all", nil)
+		h.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `{"stopped":["`+j.Container.ID+`"]}`, w.Body.String())
+	})
+
+	t.Run("cancel async start", func(t *testing.T) {
+		t.Parallel()
+
+		h := gnomockd.Handler()
+		buf := bytes.NewBufferString(`{
+			"image": "docker.io/orlangure/gnomock-test-image",
+			"healthcheck": {"type": "log", "pattern": "this line is never logged"},
+			"options": {"timeout": 60000000000}
+		}`)
+		w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/start-custom?async=true", buf)
+		h.ServeHTTP(w, r)
+
+		var j struct {
+			ID    string `json:"id"`
+			Phase string `json:"phase"`
+		}
+
+		require.Equal(t, http.StatusAccepted, w.Code)
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&j))
+
+		w, r = httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/jobs/"+j.ID, nil)
+		h.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&j))
+		require.Equal(t, "canceled", j.Phase)
+
+		w, r = httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/containers", nil)
+		h.ServeHTTP(w, r)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		require.JSONEq(t, `[]`, w.Body.String())
+	})
+
 	t.Run("fixed host port using custom named ports", func(t *testing.T) {
 		t.Parallel()
 
@@ -121,3 +588,12 @@ func TestGnomockd(t *testing.T) {
 		require.Equal(t, 43210, c.DefaultPort())
 	})
 }
+
+// post sends a POST request with the provided body to the handler, and returns
+// the recorded response.
+func post(h http.Handler, path, body string) *httptest.ResponseRecorder {
+	w, r := httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, path, bytes.NewBufferString(body))
+	h.ServeHTTP(w, r)
+
+	return w
+}
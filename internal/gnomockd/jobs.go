@@ -0,0 +1,220 @@
+package gnomockd
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/internal/errors"
+)
+
+// Phases of asynchronous start jobs.
+const (
+	phasePulling      = "pulling"
+	phaseStarting     = "starting"
+	phaseWaiting      = "waiting"
+	phaseInitializing = "initializing"
+	phaseReady        = "ready"
+	phaseFailed       = "failed"
+	phaseCanceled     = "canceled"
+)
+
+// startFunc starts a new container using the provided context and options.
+type startFunc func(ctx context.Context, opts ...gnomock.Option) (*gnomock.Container, error)
+
+// jobState is the current state of an asynchronous start job, as reported to
+// the clients.
+type jobState struct {
+	ID        string                      `json:"id"`
+	Phase     string                      `json:"phase"`
+	Container *gnomock.Container          `json:"container,omitempty"`
+	Error     string                      `json:"error,omitempty"`
+	Exit      *gnomock.ContainerExitError `json:"exit,omitempty"`
+	CreatedAt time.Time                   `json:"createdAt"`
+	UpdatedAt time.Time                   `json:"updatedAt"`
+}
+
+// job starts a container in the background, and keeps track of its progress.
+type job struct {
+	mu     sync.Mutex
+	state  jobState
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (j *job) snapshot() jobState {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.state
+}
+
+func (j *job) update(f func(*jobState)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	f(&j.state)
+	j.state.UpdatedAt = time.Now()
+}
+
+// onEvent moves the job to the next phase according to the container
+// lifecycle event.
+func (j *job) onEvent(e gnomock.Event) {
+	var phase string
+
+	switch e.Type {
+	case gnomock.EventImagePullDone, gnomock.EventContainerCreated:
+		phase = phaseStarting
+	case gnomock.EventContainerStarted:
+		phase = phaseWaiting
+	case gnomock.EventHealthy:
+		phase = phaseInitializing
+	default:
+		return
+	}
+
+	j.update(func(s *jobState) { s.Phase = phase })
+}
+
+func (j *job) run(ctx context.Context, start startFunc) {
+	defer close(j.done)
+
+	c, err := start(ctx, gnomock.WithEventHandler(j.onEvent))
+
+	j.update(func(s *jobState) {
+		s.Container = c
+
+		switch {
+		case err == nil:
+			s.Phase = phaseReady
+		case ctx.Err() != nil:
+			s.Phase, s.Error = phaseCanceled, ctx.Err().Error()
+		default:
+			s.Phase, s.Error = phaseFailed, errors.NewStartFailedError(err, c).Error()
+			_ = stderrors.As(err, &s.Exit)
+		}
+	})
+}
+
+// jobs keeps track of asynchronous start jobs. Finished jobs are kept until
+// they are deleted, so that their result can be retrieved.
+type jobs struct {
+	mu   sync.Mutex
+	byID map[string]*job
+	wg   sync.WaitGroup
+}
+
+func newJobs() *jobs {
+	return &jobs{byID: make(map[string]*job)}
+}
+
+// start runs the provided function in a new job, and returns its initial
+// state.
+func (js *jobs) start(start startFunc) jobState {
+	ctx, cancel := context.WithCancel(context.Background())
+	now := time.Now()
+
+	j := &job{
+		state: jobState{
+			ID:        uuid.NewString(),
+			Phase:     phasePulling,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+
+	js.mu.Lock()
+	js.byID[j.state.ID] = j
+	js.mu.Unlock()
+
+	js.wg.Add(1)
+
+	go func() {
+		defer js.wg.Done()
+		defer cancel()
+
+		j.run(ctx, start)
+	}()
+
+	return j.snapshot()
+}
+
+func (js *jobs) get(id string) (*job, bool) {
+	js.mu.Lock()
+	defer js.mu.Unlock()
+
+	j, ok := js.byID[id]
+
+	return j, ok
+}
+
+// remove cancels the job with the provided ID if it is still running, waits
+// for it to finish, and forgets about it. The final state of the job is
+// returned.
+func (js *jobs) remove(ctx context.Context, id string) (jobState, bool) {
+	j, ok := js.get(id)
+	if !ok {
+		return jobState{}, false
+	}
+
+	j.cancel()
+
+	select {
+	case <-j.done:
+	case <-ctx.Done():
+	}
+
+	js.mu.Lock()
+	delete(js.byID, id)
+	js.mu.Unlock()
+
+	return j.snapshot(), true
+}
+
+// cancelAll cancels all the running jobs, and waits for them to finish.
+func (js *jobs) cancelAll() {
+	js.mu.Lock()
+
+	for _, j := range js.byID {
+		j.cancel()
+	}
+
+	js.mu.Unlock()
+
+	js.wg.Wait()
+}
+
+func jobHandler(js *jobs) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		j, ok := js.get(id)
+		if !ok {
+			respondWithError(w, errors.JobNotFoundError(id))
+			return
+		}
+
+		respondWithJSON(w, j.snapshot())
+	}
+}
+
+func deleteJobHandler(js *jobs) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		state, ok := js.remove(r.Context(), id)
+		if !ok {
+			respondWithError(w, errors.JobNotFoundError(id))
+			return
+		}
+
+		respondWithJSON(w, state)
+	}
+}
@@ -74,6 +74,21 @@ func TestKafka(t *testing.T) {
 
 	require.NoError(t, eventsReader.Close())
 
+	messages := `{"messages":[{"topic":"extra","key":"reset","value":"1"}]}`
+	w = post(h, "/containers/"+c.ID+"/kafka/messages", messages)
+	require.Equalf(t, http.StatusOK, w.Code, w.Body.String())
+
+	extraReader := kafkaclient.NewReader(kafkaclient.ReaderConfig{
+		Brokers: []string{c.Address(kafka.BrokerPort)},
+		Topic:   "extra",
+	})
+
+	m, err = extraReader.ReadMessage(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "reset", string(m.Key))
+	require.Equal(t, "1", string(m.Value))
+	require.NoError(t, extraReader.Close())
+
 	bs, err = json.Marshal(c)
 	require.NoError(t, err)
 
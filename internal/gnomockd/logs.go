@@ -0,0 +1,206 @@
+package gnomockd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/orlangure/gnomock/internal/errors"
+)
+
+const eventStreamType = "text/event-stream"
+
+// logsHandler streams the logs of a tracked container. The logs are written
+// as plain text by default, or as server-sent events if the client accepts
+// them, using "stdout" and "stderr" as event types. Streams end when the
+// container stops, when the client goes away, or when the provided context is
+// canceled.
+func logsHandler(cs *containers, streams context.Context) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := mux.Vars(r)["id"]
+
+		tc, ok := cs.get(id)
+		if !ok {
+			respondWithError(w, errors.ContainerNotFoundError(id))
+			return
+		}
+
+		follow, since, err := parseLogsQuery(r, time.Now())
+		if err != nil {
+			respondWithError(w, errors.InvalidContainerRequestError(err))
+			return
+		}
+
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+
+		defer context.AfterFunc(streams, cancel)()
+
+		ls := newLogStream(w, strings.Contains(r.Header.Get("Accept"), eventStreamType))
+
+		err = tc.StreamLogs(ctx, ls.stdout, ls.stderr, since, follow)
+
+		switch {
+		case err == nil || ctx.Err() != nil:
+			ls.end()
+		case !ls.started:
+			respondWithError(w, errors.ContainerActionFailedError("logs", err, tc.Container))
+		default:
+			ls.fail(err)
+		}
+	}
+}
+
+// parseLogsQuery returns follow and since query parameters of logs request.
+// since is either a timestamp in RFC 3339 format, or a duration relative to
+// now, for example "10m".
+func parseLogsQuery(r *http.Request, now time.Time) (follow bool, since time.Time, err error) {
+	q := r.URL.Query()
+
+	if v := q.Get("follow"); v != "" {
+		follow, err = strconv.ParseBool(v)
+		if err != nil {
+			return false, since, fmt.Errorf("invalid follow parameter: %w", err)
+		}
+	}
+
+	v := q.Get("since")
+	if v == "" {
+		return follow, since, nil
+	}
+
+	if since, err = time.Parse(time.RFC3339Nano, v); err == nil {
+		return follow, since, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return false, since, fmt.Errorf("invalid since parameter %q: use RFC 3339 timestamp or duration", v)
+	}
+
+	return follow, now.Add(-d), nil
+}
+
+// logStream writes container logs into the response as soon as they arrive.
+// The response headers are only written with the first log line, so that an
+// error can still be reported properly if the logs can't be read at all. It
+// is not safe for concurrent use: all the writes should come from a single
+// log forwarder.
+type logStream struct {
+	w       http.ResponseWriter
+	sse     bool
+	started bool
+	stdout  *logEventWriter
+	stderr  *logEventWriter
+}
+
+func newLogStream(w http.ResponseWriter, sse bool) *logStream {
+	s := &logStream{w: w, sse: sse}
+	s.stdout = &logEventWriter{stream: s, event: "stdout"}
+	s.stderr = &logEventWriter{stream: s, event: "stderr"}
+
+	return s
+}
+
+func (s *logStream) start() {
+	if s.started {
+		return
+	}
+
+	s.started = true
+
+	contentType := "text/plain; charset=utf-8"
+	if s.sse {
+		contentType = eventStreamType
+	}
+
+	s.w.Header().Set("Content-Type", contentType)
+	s.w.Header().Set("Cache-Control", "no-cache")
+	s.w.Header().Set("X-Content-Type-Options", "nosniff")
+	s.w.WriteHeader(http.StatusOK)
+}
+
+// end writes the incomplete lines left in the stream, if any, and makes sure
+// the response headers are written even if there were no logs at all.
+func (s *logStream) end() {
+	for _, w := range []*logEventWriter{s.stdout, s.stderr} {
+		if len(w.partial) > 0 {
+			_ = s.write(w.event, w.partial)
+		}
+	}
+
+	s.start()
+}
+
+func (s *logStream) write(event string, p []byte) error {
+	s.start()
+
+	var err error
+
+	if s.sse {
+		_, err = fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, p)
+	} else {
+		_, err = s.w.Write(p)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return http.NewResponseController(s.w).Flush()
+}
+
+// fail reports an error that happened after the stream started. Server-sent
+// events include it as "error" event; plain text streams can't tell it apart
+// from the logs, so they simply end.
+func (s *logStream) fail(err error) {
+	log.Println("can't stream logs:", err)
+
+	if s.sse {
+		_ = s.write("error", []byte(err.Error()))
+	}
+}
+
+// logEventWriter writes the logs of a single stream, stdout or stderr. When
+// server-sent events are used, every line becomes a separate event, and
+// incomplete lines are kept until they end.
+type logEventWriter struct {
+	stream  *logStream
+	event   string
+	partial []byte
+}
+
+// Write implements io.Writer.
+func (w *logEventWriter) Write(p []byte) (int, error) {
+	if !w.stream.sse {
+		if err := w.stream.write(w.event, p); err != nil {
+			return 0, err
+		}
+
+		return len(p), nil
+	}
+
+	w.partial = append(w.partial, p...)
+
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+
+		line := bytes.TrimSuffix(w.partial[:i], []byte("\r"))
+		w.partial = w.partial[i+1:]
+
+		if err := w.stream.write(w.event, line); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
@@ -66,6 +66,31 @@ func TestPostgres(t *testing.T) {
 	require.NoError(t, row.Scan(&value))
 	require.Equal(t, 3, value)
 
+	queries := `{"queries":["create table extra (b int)","insert into extra values (7)"]}`
+	w = post(h, "/containers/"+c.ID+"/postgres/queries", queries)
+	require.Equalf(t, http.StatusOK, w.Code, w.Body.String())
+
+	row = db.QueryRow(`select b from extra`)
+	value = 0
+	require.NoError(t, row.Scan(&value))
+	require.Equal(t, 7, value)
+
+	w = post(h, "/containers/"+c.ID+"/postgres/queries", `{"queries":"foo"}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = post(h, "/containers/"+c.ID+"/postgres/queries", `{"queries":["select * from unknown"]}`)
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+
+	w = post(h, "/containers/"+c.ID+"/postgres/foobar", `{}`)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w = post(h, "/containers/"+c.ID+"/kafka/messages", `{}`)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = post(h, "/containers/"+c.ID+"/exec", `{"cmd":["psql","-U","postgres","-tAc","select 1"]}`)
+	require.Equalf(t, http.StatusOK, w.Code, w.Body.String())
+	require.JSONEq(t, `{"stdout":"1\n","stderr":"","exitCode":0}`, w.Body.String())
+
 	bs, err = json.Marshal(c)
 	require.NoError(t, err)
 
@@ -76,3 +101,30 @@ func TestPostgres(t *testing.T) {
 	res = w.Result()
 	require.Equal(t, http.StatusOK, res.StatusCode)
 }
+
+func TestPostgres_inDocker(t *testing.T) {
+	// this test cannot run in parallel with other tests since it modifies the
+	// environment, which affects other tests
+	t.Setenv("GNOMOCK_ENV", "gnomockd")
+
+	h := gnomockd.Handler()
+	w := post(h, "/start/postgres", `{"preset":{"version":"12"}}`)
+	require.Equalf(t, http.StatusOK, w.Code, w.Body.String())
+
+	var c *gnomock.Container
+
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &c))
+
+	t.Cleanup(func() {
+		w := post(h, "/stop", `{"id":"`+c.ID+`"}`)
+		require.Equal(t, http.StatusOK, w.Code)
+	})
+
+	queries := `{"queries":["create table extra (b int)","insert into extra values (7)"]}`
+	w = post(h, "/containers/"+c.ID+"/postgres/queries", queries)
+	require.Equalf(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = post(h, "/containers/"+c.ID+"/exec", `{"cmd":["psql","-U","postgres","-tAc","select b from extra"]}`)
+	require.Equalf(t, http.StatusOK, w.Code, w.Body.String())
+	require.JSONEq(t, `{"stdout":"7\n","stderr":"","exitCode":0}`, w.Body.String())
+}
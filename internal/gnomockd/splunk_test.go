@@ -43,6 +43,12 @@ func TestSplunk(t *testing.T) {
 	err = json.Unmarshal(body, &c)
 	require.NoError(t, err)
 
+	events := `{"events":[{
+		"event": "extra sale", "index": "sales", "source": "gnomockd", "sourcetype": "json", "time": 1577836800
+	}]}`
+	w = post(h, "/containers/"+c.ID+"/splunk/events", events)
+	require.Equalf(t, http.StatusOK, w.Code, w.Body.String())
+
 	client := &http.Client{
 		Transport: &http.Transport{
 			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
@@ -75,7 +81,7 @@ func TestSplunk(t *testing.T) {
 	bs, err = io.ReadAll(res.Body)
 	require.NoError(t, err)
 	require.NoError(t, json.Unmarshal(bs, &out))
-	require.Equal(t, "525", out.Result.Count)
+	require.Equal(t, "526", out.Result.Count)
 
 	bs, err = json.Marshal(c)
 	require.NoError(t, err)
@@ -2,11 +2,14 @@ package gnomockd
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/orlangure/gnomock"
@@ -14,7 +17,7 @@ import (
 	"github.com/orlangure/gnomock/internal/registry"
 )
 
-func startHandler() http.HandlerFunc {
+func startHandler(cs *containers, js *jobs) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		name := vars["name"]
@@ -34,31 +37,101 @@ func startHandler() http.HandlerFunc {
 			return
 		}
 
-		started := make(chan bool)
-		logWriter, allLogs := setupLogWriter(started)
+		tc := &trackedContainer{Preset: name, Config: p, Options: sr.Options}
 
-		c, err := gnomock.Start(
-			p,
-			gnomock.WithOptions(&sr.Options),
-			gnomock.WithLogWriter(logWriter),
-			gnomock.WithContext(r.Context()),
-		)
+		startContainer(w, r, cs, js, tc, func(opts ...gnomock.Option) (*gnomock.Container, error) {
+			opts = append([]gnomock.Option{gnomock.WithOptions(&sr.Options)}, opts...)
+			return gnomock.Start(p, opts...)
+		})
+	}
+}
 
-		close(started)
+// startContainer starts a new container using the provided function, and
+// responds with the started container, which becomes tracked by the server.
+// If async query parameter is set, the container is started in a new job,
+// and the response includes the job instead.
+func startContainer(
+	w http.ResponseWriter,
+	r *http.Request,
+	cs *containers,
+	js *jobs,
+	tc *trackedContainer,
+	start func(...gnomock.Option) (*gnomock.Container, error),
+) {
+	async, err := isAsync(r)
+	if err != nil {
+		respondWithError(w, errors.NewInvalidStartRequestError(err))
+		return
+	}
 
-		if err != nil {
-			err = fmt.Errorf("%s: %w", strings.Join(<-allLogs, ";"), err)
-			respondWithError(w, errors.NewStartFailedError(err, c))
+	run := func(ctx context.Context, opts ...gnomock.Option) (*gnomock.Container, error) {
+		return runStart(ctx, cs, tc, start, opts...)
+	}
 
-			return
-		}
+	if async {
+		w.WriteHeader(http.StatusAccepted)
+		respondWithJSON(w, js.start(run))
 
-		err = json.NewEncoder(w).Encode(c)
-		if err != nil {
-			respondWithError(w, errors.NewStartFailedError(err, c))
-			return
-		}
+		return
 	}
+
+	c, err := run(r.Context())
+	if err != nil {
+		respondWithError(w, errors.NewStartFailedError(err, c))
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(c)
+	if err != nil {
+		respondWithError(w, errors.NewStartFailedError(err, c))
+		return
+	}
+}
+
+// runStart starts a new container using the provided function, and tracks it
+// once it is ready. Container logs are included in the error if the
+// container fails to start.
+func runStart(
+	ctx context.Context,
+	cs *containers,
+	tc *trackedContainer,
+	start func(...gnomock.Option) (*gnomock.Container, error),
+	opts ...gnomock.Option,
+) (*gnomock.Container, error) {
+	started := make(chan bool)
+	logWriter, allLogs := setupLogWriter(started)
+
+	opts = append([]gnomock.Option{
+		gnomock.WithLogWriter(logWriter),
+		gnomock.WithContext(ctx),
+	}, opts...)
+
+	c, err := start(opts...)
+
+	close(started)
+
+	if err != nil {
+		return c, fmt.Errorf("%s: %w", strings.Join(<-allLogs, ";"), err)
+	}
+
+	tc.Container, tc.StartedAt = c, time.Now()
+	cs.add(tc)
+
+	return c, nil
+}
+
+func isAsync(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("async")
+	if v == "" {
+		return false, nil
+	}
+
+	async, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("invalid async parameter: %w", err)
+	}
+
+	return async, nil
 }
 
 func setupLogWriter(done chan bool) (io.Writer, chan []string) {
@@ -0,0 +1,142 @@
+package gnomockd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/health"
+	"github.com/orlangure/gnomock/internal/errors"
+)
+
+// Supported types of declarative healthchecks of custom containers.
+const (
+	healthcheckTCP  = "tcp"
+	healthcheckHTTP = "http"
+	healthcheckLog  = "log"
+)
+
+func startCustomHandler(cs *containers, js *jobs) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var sr startCustomRequest
+
+		err := json.NewDecoder(r.Body).Decode(&sr)
+		if err != nil {
+			respondWithError(w, errors.NewInvalidStartRequestError(err))
+			return
+		}
+
+		opts, err := sr.options()
+		if err != nil {
+			respondWithError(w, errors.NewInvalidStartRequestError(err))
+			return
+		}
+
+		tc := &trackedContainer{Image: sr.Image, Options: sr.Options}
+
+		startContainer(w, r, cs, js, tc, func(extra ...gnomock.Option) (*gnomock.Container, error) {
+			return gnomock.StartCustom(sr.Image, sr.Ports, append(opts, extra...)...)
+		})
+	}
+}
+
+// startCustomRequest describes a container started from an arbitrary image,
+// similar to gnomock.StartCustom.
+type startCustomRequest struct {
+	Image       string             `json:"image"`
+	Ports       gnomock.NamedPorts `json:"ports"`
+	Env         []string           `json:"env"`
+	Cmd         []string           `json:"cmd"`
+	Healthcheck *customHealthcheck `json:"healthcheck"`
+	Options     gnomock.Options    `json:"options"`
+}
+
+func (sr *startCustomRequest) options() ([]gnomock.Option, error) {
+	if sr.Image == "" {
+		return nil, fmt.Errorf("missing image")
+	}
+
+	opts := []gnomock.Option{gnomock.WithOptions(&sr.Options)}
+
+	for _, env := range sr.Env {
+		opts = append(opts, gnomock.WithEnv(env))
+	}
+
+	if len(sr.Cmd) > 0 {
+		opts = append(opts, gnomock.WithCommand(sr.Cmd[0], sr.Cmd[1:]...))
+	}
+
+	if sr.Healthcheck != nil {
+		opt, err := sr.Healthcheck.option(sr.Ports)
+		if err != nil {
+			return nil, fmt.Errorf("invalid healthcheck: %w", err)
+		}
+
+		opts = append(opts, opt)
+	}
+
+	return opts, nil
+}
+
+// customHealthcheck is a declarative healthcheck of a custom container. Port
+// is the name of one of the container ports, and defaults to
+// gnomock.DefaultPort.
+type customHealthcheck struct {
+	Type string `json:"type"`
+	Port string `json:"port"`
+
+	// http healthcheck sends a GET request to the path, and expects one of
+	// the provided status codes, or any status below 400 if there are none.
+	// If body is set, the response should include it.
+	Path   string `json:"path"`
+	Status []int  `json:"status"`
+	Body   string `json:"body"`
+
+	// log healthcheck waits for a line in container logs matching the
+	// pattern. It uses the existing log stream of the container instead of
+	// reading the logs again on every check.
+	Pattern string `json:"pattern"`
+}
+
+// option returns an option that makes Gnomock wait for this healthcheck to
+// pass.
+func (h *customHealthcheck) option(ports gnomock.NamedPorts) (gnomock.Option, error) {
+	port := h.Port
+	if port == "" {
+		port = gnomock.DefaultPort
+	}
+
+	if h.Type == healthcheckTCP || h.Type == healthcheckHTTP {
+		if _, ok := ports[port]; !ok {
+			return nil, fmt.Errorf("unknown port '%s'", port)
+		}
+	}
+
+	switch h.Type {
+	case healthcheckTCP:
+		return gnomock.WithHealthCheck(health.TCP(port)), nil
+	case healthcheckHTTP:
+		var opts []health.HTTPOption
+
+		if len(h.Status) > 0 {
+			opts = append(opts, health.ExpectStatus(h.Status...))
+		}
+
+		if h.Body != "" {
+			opts = append(opts, health.ExpectBodyContains(h.Body))
+		}
+
+		return gnomock.WithHealthCheck(health.HTTP(port, h.Path, opts...)), nil
+	case healthcheckLog:
+		re, err := regexp.Compile(h.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern: %w", err)
+		}
+
+		return gnomock.WithWaitForLog(re, 1), nil
+	default:
+		return nil, fmt.Errorf("unsupported type '%s'", h.Type)
+	}
+}
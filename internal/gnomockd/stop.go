@@ -9,7 +9,7 @@ import (
 	"github.com/orlangure/gnomock/internal/errors"
 )
 
-func stopHandler() http.HandlerFunc {
+func stopHandler(cs *containers) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var sr stopRequest
 
@@ -24,7 +24,12 @@ func stopHandler() http.HandlerFunc {
 			return
 		}
 
+		// containers started by this server are stopped using the original
+		// container, which also releases its log stream
 		c := &gnomock.Container{ID: sr.ID}
+		if tc, ok := cs.get(sr.ID); ok {
+			c = tc.Container
+		}
 
 		err = gnomock.Stop(c)
 		if err != nil {
@@ -32,6 +37,7 @@ func stopHandler() http.HandlerFunc {
 			return
 		}
 
+		cs.remove(sr.ID)
 		w.WriteHeader(http.StatusOK)
 	}
 }
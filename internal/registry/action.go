@@ -0,0 +1,60 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/orlangure/gnomock"
+)
+
+// ErrInvalidActionRequest means that action request couldn't be decoded.
+var ErrInvalidActionRequest = errors.New("invalid action request")
+
+// Action runs a preset specific operation on a running container, for example
+// to add more data to it after it started. p is the preset that was used to
+// start the container, and req is JSON encoded action request.
+type Action func(ctx context.Context, c *gnomock.Container, p gnomock.Preset, req []byte) error
+
+var (
+	actionsMu sync.RWMutex
+	actions   = map[string]Action{}
+)
+
+// RegisterAction makes the provided function discoverable as an action with
+// the provided name of the provided preset. Action request is decoded into a
+// new value of type T before the function is called.
+func RegisterAction[T any](
+	preset, action string,
+	f func(ctx context.Context, c *gnomock.Container, p gnomock.Preset, req T) error,
+) {
+	actionsMu.Lock()
+	defer actionsMu.Unlock()
+
+	actions[actionKey(preset, action)] = func(
+		ctx context.Context, c *gnomock.Container, p gnomock.Preset, bs []byte,
+	) error {
+		var req T
+
+		if err := json.Unmarshal(bs, &req); err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidActionRequest, err)
+		}
+
+		return f(ctx, c, p, req)
+	}
+}
+
+// FindAction returns an action registered under the provided preset and
+// action names, or nil if there is no such action.
+func FindAction(preset, action string) Action {
+	actionsMu.RLock()
+	defer actionsMu.RUnlock()
+
+	return actions[actionKey(preset, action)]
+}
+
+func actionKey(preset, action string) string {
+	return preset + "/" + action
+}
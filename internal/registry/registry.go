@@ -1,27 +1,30 @@
 // Package registry provides access to existing presets. Every preset is
 // required to call `Register` in order to become discoverable in the registry.
+// Presets may also call `RegisterAction` to expose operations that can run on
+// their containers after they start.
 package registry
 
 import (
 	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/internal/catalog"
 )
 
 type presetFactory func() gnomock.Preset
 
-var registry = map[string]presetFactory{}
-
 // Register makes the provided preset discoverable by the provided name.
 func Register(name string, p presetFactory) {
-	registry[name] = p
+	catalog.Add(name, func() any { return p() })
 }
 
 // Find returns a preset registered under the provided name, or nil if such
 // name is not registered.
 func Find(name string) gnomock.Preset {
-	p, ok := registry[name]
+	f, ok := catalog.Get(name)
 	if !ok {
 		return nil
 	}
 
-	return p()
+	p, _ := f().(gnomock.Preset)
+
+	return p
 }
@@ -1,6 +1,7 @@
 package registry_test
 
 import (
+	"context"
 	"testing"
 
 	"github.com/orlangure/gnomock"
@@ -20,3 +21,29 @@ func TestRegistry(t *testing.T) {
 	require.Equal(t, p, registry.Find("preset"))
 	require.Nil(t, registry.Find("invalid"))
 }
+
+func TestRegisterAction(t *testing.T) {
+	type request struct {
+		Value int `json:"value"`
+	}
+
+	var got int
+
+	action := func(_ context.Context, _ *gnomock.Container, _ gnomock.Preset, req request) error {
+		got = req.Value
+		return nil
+	}
+
+	registry.RegisterAction("preset", "action", action)
+
+	found := registry.FindAction("preset", "action")
+	require.NotNil(t, found)
+	require.NoError(t, found(context.Background(), nil, nil, []byte(`{"value":42}`)))
+	require.Equal(t, 42, got)
+
+	err := found(context.Background(), nil, nil, []byte(`{"value":"foo"}`))
+	require.ErrorIs(t, err, registry.ErrInvalidActionRequest)
+
+	require.Nil(t, registry.FindAction("preset", "invalid"))
+	require.Nil(t, registry.FindAction("invalid", "action"))
+}
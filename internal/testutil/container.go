@@ -20,3 +20,11 @@ func ListContainerByID(cli *client.Client, id string) ([]container.Summary, erro
 
 	return result.Items, nil
 }
+
+// RemoveImage removes the image with the provided name, for example a snapshot
+// created during a test.
+func RemoveImage(cli *client.Client, image string) error {
+	_, err := cli.ImageRemove(context.Background(), image, client.ImageRemoveOptions{Force: true})
+
+	return err
+}
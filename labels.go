@@ -0,0 +1,110 @@
+package gnomock
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Labels set by Gnomock on every container and network it creates.
+const (
+	// LabelSession is set to a unique identifier of the process that created
+	// the container. All containers created by the same test binary run share
+	// the same session.
+	LabelSession = "gnomock.session"
+
+	// LabelPreset is set to the name of the preset used to create the
+	// container, if any.
+	LabelPreset = "gnomock.preset"
+
+	// LabelCreated is set to container creation time in RFC 3339 format.
+	LabelCreated = "gnomock.created"
+
+	// LabelBinary is set to the name of the binary, usually a test binary,
+	// that created the container.
+	LabelBinary = "gnomock.binary"
+
+	// LabelReuse is set on containers created with WithContainerReuse. Such
+	// containers are not removed by Sweep.
+	LabelReuse = "gnomock.reuse"
+)
+
+// session uniquely identifies the current process. It allows to tell
+// containers created by this process from leftovers of previous runs.
+var session = uuid.NewString()
+
+// WithLabels adds the provided labels to the container, in addition to the
+// labels Gnomock sets on its own. Labels can be used to find containers
+// created by Gnomock, for example using `docker ps --filter label=key=value`.
+func WithLabels(labels map[string]string) Option {
+	return func(o *Options) {
+		if o.Labels == nil {
+			o.Labels = make(map[string]string, len(labels))
+		}
+
+		for k, v := range labels {
+			o.Labels[k] = v
+		}
+	}
+}
+
+// withPreset sets the name of the preset used to create the container.
+func withPreset(p Preset) Option {
+	return func(o *Options) {
+		o.presetName = presetName(p)
+	}
+}
+
+// presetName returns the name of the provided preset type, for example
+// "postgres.P". Presets defined in environment files are named after the
+// presets they wrap, and custom containers have no preset name.
+func presetName(p Preset) string {
+	switch p := p.(type) {
+	case *envPreset:
+		return presetName(p.Preset)
+	case *customPreset:
+		return ""
+	default:
+		return strings.TrimPrefix(fmt.Sprintf("%T", p), "*")
+	}
+}
+
+// labels returns all the labels to set on a new container or network.
+func (o *Options) labels() map[string]string {
+	labels := make(map[string]string, len(o.Labels)+4)
+
+	for k, v := range o.Labels {
+		labels[k] = v
+	}
+
+	labels[LabelSession] = session
+	labels[LabelCreated] = time.Now().UTC().Format(time.RFC3339)
+	labels[LabelBinary] = filepath.Base(os.Args[0])
+
+	if o.presetName != "" {
+		labels[LabelPreset] = o.presetName
+	}
+
+	if o.Reuse {
+		labels[LabelReuse] = "true"
+	}
+
+	return labels
+}
+
+// Sweep removes containers, including sidecars, and networks created by
+// Gnomock more than olderThan ago. Only leftovers of other processes are
+// removed: containers created by the current process, as well as containers
+// created with WithContainerReuse, are not affected. Use
+// Sweep to clean up after crashed test runs, or runs that used
+// WithDebugMode or WithDisableAutoCleanup.
+func Sweep(ctx context.Context, olderThan time.Duration) error {
+	return withDocker(func(cli *docker) error {
+		return cli.sweep(ctx, time.Now().Add(-olderThan))
+	})
+}
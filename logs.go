@@ -0,0 +1,166 @@
+package gnomock
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const (
+	// exitLogLines is the number of log lines reported in
+	// ContainerExitError.
+	exitLogLines = 50
+
+	// exitLogsTimeout limits the time spent reading logs of a container that
+	// exited.
+	exitLogsTimeout = time.Second * 5
+)
+
+// Logs returns everything this container wrote to stdout and stderr so far.
+func (c *Container) Logs(ctx context.Context) (logs []byte, err error) {
+	err = withDocker(func(cli *docker) error {
+		logs, err = cli.logs(ctx, c.DockerID())
+		return err
+	})
+
+	return logs, err
+}
+
+// StreamLogs writes the logs of this container into stdout and stderr as
+// soon as they arrive, starting at since. Zero since includes all the logs
+// written so far. If follow is false, StreamLogs returns once the existing
+// logs are written; otherwise it keeps writing new logs until the container
+// stops or the context is canceled.
+func (c *Container) StreamLogs(
+	ctx context.Context,
+	stdout, stderr io.Writer,
+	since time.Time,
+	follow bool,
+) error {
+	return withDocker(func(cli *docker) error {
+		return cli.streamLogs(ctx, c.DockerID(), stdout, stderr, since, follow)
+	})
+}
+
+// logMatcher is an io.Writer that receives container logs, and counts the
+// lines matching the provided pattern. Once the expected number of matching
+// lines is found, done channel is closed. It is not safe for concurrent use:
+// all the writes should come from a single log forwarder.
+type logMatcher struct {
+	re    *regexp.Regexp
+	want  int
+	count int
+	buf   []byte
+	done  chan struct{}
+}
+
+func newLogMatcher(re *regexp.Regexp, occurrences int) *logMatcher {
+	if occurrences < 1 {
+		occurrences = 1
+	}
+
+	return &logMatcher{re: re, want: occurrences, done: make(chan struct{})}
+}
+
+// Write implements io.Writer. It never fails so that other writers sharing
+// the same log stream are not affected.
+func (m *logMatcher) Write(p []byte) (int, error) {
+	if m.count >= m.want {
+		return len(p), nil
+	}
+
+	m.buf = append(m.buf, p...)
+
+	for {
+		i := bytes.IndexByte(m.buf, '\n')
+		if i < 0 {
+			break
+		}
+
+		line := m.buf[:i]
+		m.buf = m.buf[i+1:]
+
+		if !m.re.Match(line) {
+			continue
+		}
+
+		m.count++
+
+		if m.count == m.want {
+			close(m.done)
+			m.buf = nil
+
+			break
+		}
+	}
+
+	return len(p), nil
+}
+
+// logTail is an io.Writer that keeps the last lines of container logs. It is
+// safe for concurrent use, so that the lines can be read while the logs are
+// still being written. done channel is closed once the log stream ends.
+type logTail struct {
+	mu      sync.Mutex
+	size    int
+	buf     []string
+	partial []byte
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newLogTail(size int) *logTail {
+	return &logTail{size: size, done: make(chan struct{})}
+}
+
+// Write implements io.Writer. It never fails so that other writers sharing
+// the same log stream are not affected.
+func (t *logTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.partial = append(t.partial, p...)
+
+	for {
+		i := bytes.IndexByte(t.partial, '\n')
+		if i < 0 {
+			break
+		}
+
+		t.buf = append(t.buf, string(t.partial[:i]))
+		t.partial = t.partial[i+1:]
+	}
+
+	if len(t.buf) > t.size {
+		t.buf = append(t.buf[:0], t.buf[len(t.buf)-t.size:]...)
+	}
+
+	return len(p), nil
+}
+
+// lines returns the last lines written so far, including the last
+// incomplete line.
+func (t *logTail) lines() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines := append([]string(nil), t.buf...)
+
+	if len(t.partial) > 0 {
+		lines = append(lines, string(t.partial))
+	}
+
+	if len(lines) > t.size {
+		lines = lines[len(lines)-t.size:]
+	}
+
+	return lines
+}
+
+func (t *logTail) close() {
+	t.closeOnce.Do(func() { close(t.done) })
+}
@@ -0,0 +1,56 @@
+package gnomock_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainer_StreamLogs(t *testing.T) {
+	t.Parallel()
+
+	container, err := gnomock.StartCustom(
+		"docker.io/library/busybox:1.35.0",
+		gnomock.DefaultTCP(testutil.GoodPort80),
+		gnomock.WithCommand("sh", "-c", "echo out; echo err >&2; sleep 60"),
+	)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, gnomock.Stop(container))
+	}()
+
+	ctx := context.Background()
+
+	t.Run("existing logs", func(t *testing.T) {
+		var stdout, stderr strings.Builder
+
+		require.NoError(t, container.StreamLogs(ctx, &stdout, &stderr, time.Time{}, false))
+		require.Equal(t, "out\n", stdout.String())
+		require.Equal(t, "err\n", stderr.String())
+	})
+
+	t.Run("since", func(t *testing.T) {
+		var stdout, stderr strings.Builder
+
+		require.NoError(t, container.StreamLogs(ctx, &stdout, &stderr, time.Now().Add(time.Hour), false))
+		require.Empty(t, stdout.String())
+		require.Empty(t, stderr.String())
+	})
+
+	t.Run("follow until canceled", func(t *testing.T) {
+		var stdout, stderr strings.Builder
+
+		ctx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+
+		err := container.StreamLogs(ctx, &stdout, &stderr, time.Time{}, true)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Equal(t, "out\n", stdout.String())
+	})
+}
@@ -0,0 +1,106 @@
+package gnomock
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// Network represents a user-defined docker network created by Gnomock.
+// Containers attached to the same network can reach each other using their
+// aliases as host names, without going through the ports published on the
+// host.
+type Network struct {
+	// A unique identifier of this network. The format of this ID may change
+	// in the future.
+	ID string `json:"id,omitempty"`
+
+	// Name of this network as known to Docker. Use it with WithNetwork to
+	// attach new containers to this network.
+	Name string `json:"name,omitempty"`
+
+	// releases the connection to the cleaner sidecar, if any
+	cleanupCancel context.CancelFunc
+}
+
+// DockerID returns the ID of this network as known to Docker.
+func (n *Network) DockerID() string {
+	id, _ := parseID(n.ID)
+	return id
+}
+
+// NewNetwork creates a new throwaway bridge network with a random name. Use
+// the name of the returned network with WithNetwork option to attach
+// containers to it. The network should be removed using RemoveNetwork when no
+// longer needed. Unless auto cleanup is disabled, the network is also
+// removed automatically when the tests complete.
+//
+// Only some of the options apply to networks: WithContext, WithDebugMode,
+// WithDisableAutoCleanup and WithPullPolicy.
+func NewNetwork(opts ...Option) (*Network, error) {
+	config := buildConfig(opts...)
+
+	g, err := newG(config.Debug)
+	if err != nil {
+		return nil, fmt.Errorf("can't create new gnomock session: %w", err)
+	}
+
+	defer func() { _ = g.log.Sync() }()
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return nil, fmt.Errorf("can't generate network name: %w", err)
+	}
+
+	name := "gnomock-" + id.String()
+
+	cli, err := g.dockerConnect()
+	if err != nil {
+		return nil, fmt.Errorf("can't create docker client: %w", err)
+	}
+
+	defer func() { _ = cli.stopClient() }()
+
+	n, err := cli.createNetwork(config.ctx, name, config)
+	if err != nil {
+		return nil, fmt.Errorf("can't create network: %w", err)
+	}
+
+	g.log.Infow("network is ready to use", "id", n.ID, "name", n.Name)
+
+	return n, nil
+}
+
+// RemoveNetwork removes the provided networks from the system. Containers that
+// are still attached to these networks are disconnected from them first.
+// RemoveNetwork returns an error if any one of the networks couldn't be
+// removed.
+func RemoveNetwork(ns ...*Network) error {
+	return withDocker(func(cli *docker) error {
+		for _, n := range ns {
+			if n == nil {
+				continue
+			}
+
+			cli.log.Infow("removing", "network", n)
+
+			id, sidecar := parseID(n.ID)
+
+			if sidecar != "" {
+				_ = cli.StopContainer(context.Background(), sidecar)
+				_ = cli.RemoveContainer(context.Background(), sidecar)
+			}
+
+			if n.cleanupCancel != nil {
+				n.cleanupCancel()
+			}
+
+			if err := cli.removeNetwork(context.Background(), id); err != nil {
+				return fmt.Errorf("can't remove network: %w", err)
+			}
+		}
+
+		return nil
+	})
+}
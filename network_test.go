@@ -0,0 +1,59 @@
+package gnomock_test
+
+import (
+	"testing"
+
+	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetwork(t *testing.T) {
+	t.Parallel()
+
+	n, err := gnomock.NewNetwork()
+	require.NoError(t, err)
+	require.NotEmpty(t, n.Name)
+
+	defer func() {
+		require.NoError(t, gnomock.RemoveNetwork(n))
+	}()
+
+	namedPorts := gnomock.NamedPorts{
+		"web80":   gnomock.TCP(testutil.GoodPort80),
+		"web8080": gnomock.TCP(testutil.GoodPort8080),
+	}
+	target, err := gnomock.StartCustom(
+		testutil.TestImage, namedPorts,
+		gnomock.WithHealthCheck(testutil.Healthcheck),
+		gnomock.WithNetwork(n.Name, "target"),
+	)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, gnomock.Stop(target))
+	}()
+
+	require.Equal(t, "target:80", target.InternalAddress("web80"))
+	require.Equal(t, "target:8080", target.InternalAddress("web8080"))
+	require.Empty(t, target.InternalAddress("unknown"))
+
+	t.Run("reachable by alias", func(t *testing.T) {
+		c, err := gnomock.StartCustom(
+			"docker.io/library/busybox:1.35.0",
+			gnomock.DefaultTCP(testutil.GoodPort80),
+			gnomock.WithNetwork(n.Name),
+			gnomock.WithCommand("wget", "-q", "-O", "-", "http://"+target.InternalAddress("web80")),
+		)
+		require.NoError(t, err)
+		require.NotEmpty(t, c.InternalAddress(gnomock.DefaultPort))
+		require.NoError(t, gnomock.Stop(c))
+	})
+
+	t.Run("not attached to network", func(t *testing.T) {
+		c, err := gnomock.StartCustom(testutil.TestImage, namedPorts)
+		require.NoError(t, err)
+		require.Empty(t, c.InternalAddress("web80"))
+		require.NoError(t, gnomock.Stop(c))
+	})
+}
@@ -2,8 +2,13 @@ package gnomock
 
 import (
 	"context"
+	"fmt"
 	"io"
+	"regexp"
+	"strings"
 	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
 const (
@@ -52,6 +57,64 @@ func WithHealthCheckInterval(t time.Duration) Option {
 	}
 }
 
+// WithHealthCheckBackoff makes the interval between two consecutive health
+// check calls grow exponentially: it starts at initial, and doubles after
+// every failed check until it reaches maxInterval. Every interval is also
+// randomly changed by up to jitter fraction of it in either direction, for
+// example by up to 10% for 0.1, so that multiple containers don't check their
+// health at the same time. Use it for containers that take long to start, to
+// avoid overloading them with health checks.
+func WithHealthCheckBackoff(initial, maxInterval time.Duration, jitter float64) Option {
+	return func(o *Options) {
+		o.healthcheckInterval = initial
+		o.healthcheckMaxInterval = maxInterval
+		o.healthcheckJitter = jitter
+	}
+}
+
+// WithDockerHealthCheck makes Gnomock wait until docker reports the container
+// as healthy, in addition to the health check set using WithHealthCheck. By
+// default, it relies on the HEALTHCHECK instruction of the image. If a
+// command is provided, it replaces the healthcheck of the image: docker runs
+// it inside the container, and considers the container healthy once it exits
+// with code 0. For example:
+//
+//	gnomock.WithDockerHealthCheck("pg_isready", "-U", "postgres")
+//
+// If no command is provided and the image doesn't define a healthcheck, Start
+// fails with ErrNoDockerHealthcheck as soon as the container starts.
+//
+// Docker health checks are only available with the default runtime.
+func WithDockerHealthCheck(cmd ...string) Option {
+	return func(o *Options) {
+		o.dockerHealthcheck = true
+		o.dockerHealthcheckCmd = cmd
+	}
+}
+
+// WithWaitForLog makes Gnomock wait until container logs include at least
+// the provided number of lines matching the pattern. It uses the same log
+// stream as WithLogWriter, so it is a cheaper alternative to healthchecks that
+// need to establish client connections. If a healthcheck is also configured,
+// both must pass: the healthcheck starts after the log pattern is matched.
+// Waiting for logs respects the timeout set with WithTimeout.
+func WithWaitForLog(pattern *regexp.Regexp, occurrences int) Option {
+	return func(o *Options) {
+		o.waitForLog = pattern
+		o.waitForLogOccurrences = occurrences
+	}
+}
+
+// WithEventHandler sets a function to receive container lifecycle events,
+// such as image pull progress, healthcheck failures or init completion. Every
+// event includes the time it took to complete the corresponding step. Events
+// are reported regardless of debug mode.
+func WithEventHandler(h EventHandler) Option {
+	return func(o *Options) {
+		o.eventHandler = h
+	}
+}
+
 // WithTimeout sets the amount of time to wait for a created container to
 // become ready to use. All startup steps must complete before they time out:
 // start, wait until healthy, init.
@@ -79,9 +142,8 @@ func WithLogWriter(w io.Writer) Option {
 
 // WithDebugMode allows Gnomock to output internal messages for debug purposes.
 // Containers created in debug mode will not be automatically removed on
-// failure to setup their initial state. Containers still might be removed if
-// they are shut down from the inside. Use WithLogWriter to see what happens
-// inside.
+// failure to setup their initial state, including containers that are shut
+// down from the inside. Use WithLogWriter to see what happens inside.
 func WithDebugMode() Option {
 	return func(o *Options) {
 		o.Debug = true
@@ -121,6 +183,48 @@ func WithOptions(options *Options) Option {
 			o.CustomNamedPorts = options.CustomNamedPorts
 		}
 
+		if options.PullPolicy != "" {
+			o.PullPolicy = options.PullPolicy
+		}
+
+		if options.UseLocalImagesFirst {
+			o.UseLocalImagesFirst = true
+		}
+
+		if options.Platform != "" {
+			o.Platform = options.Platform
+		}
+
+		if len(options.Labels) > 0 {
+			WithLabels(options.Labels)(o)
+		}
+
+		if options.MemoryLimit > 0 {
+			o.MemoryLimit = options.MemoryLimit
+		}
+
+		if options.CPUs > 0 {
+			o.CPUs = options.CPUs
+		}
+
+		if options.ShmSize > 0 {
+			o.ShmSize = options.ShmSize
+		}
+
+		o.Ulimits = append(o.Ulimits, options.Ulimits...)
+
+		for path, opts := range options.Tmpfs {
+			WithTmpfs(path, opts)(o)
+		}
+
+		if len(options.Files) > 0 {
+			WithFiles(options.Files)(o)
+		}
+
+		for name, aliases := range options.Networks {
+			WithNetwork(name, aliases...)(o)
+		}
+
 		o.Env = append(o.Env, options.Env...)
 		o.Debug = options.Debug
 		o.ContainerName = options.ContainerName
@@ -155,6 +259,102 @@ func WithHostMounts(src, dst string) Option {
 	}
 }
 
+// WithFiles copies the provided files into the container before it starts,
+// so that they exist at first boot. Keys are absolute paths inside the
+// container, and values are file contents. Missing parent directories are
+// created automatically.
+//
+// Unlike WithHostMounts, the files don't need to exist on the docker host, so
+// this option works with remote docker daemons as well.
+func WithFiles(files map[string][]byte) Option {
+	return func(o *Options) {
+		if o.Files == nil {
+			o.Files = make(map[string][]byte, len(files))
+		}
+
+		for dst, content := range files {
+			o.Files[dst] = content
+		}
+	}
+}
+
+// WithFileFrom copies a file or a directory located at `src` on the machine
+// running Gnomock into the container under `dst` path, before the container
+// starts.
+//
+// Unlike WithHostMounts, the files don't need to exist on the docker host, so
+// this option works with remote docker daemons as well.
+func WithFileFrom(src, dst string) Option {
+	return func(o *Options) {
+		if o.filesFrom == nil {
+			o.filesFrom = make(map[string]string)
+		}
+
+		o.filesFrom[dst] = src
+	}
+}
+
+// WithMemoryLimit sets the maximum amount of memory, in bytes, the container
+// can use. It is similar to the `--memory` flag of docker.
+func WithMemoryLimit(bytes int64) Option {
+	return func(o *Options) {
+		o.MemoryLimit = bytes
+	}
+}
+
+// WithCPUs sets the number of CPUs the container can use, for example 1.5. It
+// is similar to the `--cpus` flag of docker.
+func WithCPUs(cpus float64) Option {
+	return func(o *Options) {
+		o.CPUs = cpus
+	}
+}
+
+// WithShmSize sets the size of /dev/shm in the container, in bytes. It is
+// similar to the `--shm-size` flag of docker.
+func WithShmSize(bytes int64) Option {
+	return func(o *Options) {
+		o.ShmSize = bytes
+	}
+}
+
+// WithUlimits sets resource limits of processes in the container, such as
+// the maximum number of open files ("nofile"). It is similar to the
+// `--ulimit` flag of docker.
+func WithUlimits(ulimits ...Ulimit) Option {
+	return func(o *Options) {
+		o.Ulimits = append(o.Ulimits, ulimits...)
+	}
+}
+
+// WithTmpfs mounts an in-memory tmpfs file system at the provided path inside
+// the container. Mount options, such as "rw,size=512m", are optional. It is
+// similar to the `--tmpfs` flag of docker.
+//
+// Using tmpfs for database data directories, for example
+// "/var/lib/postgresql/data", makes tests significantly faster.
+func WithTmpfs(path, opts string) Option {
+	return func(o *Options) {
+		if o.Tmpfs == nil {
+			o.Tmpfs = make(map[string]string)
+		}
+
+		o.Tmpfs[path] = opts
+	}
+}
+
+// Ulimit is a resource limit of processes running in a container.
+type Ulimit struct {
+	// Name of the limit, for example "nofile" or "nproc".
+	Name string `json:"name"`
+
+	// Soft limit value.
+	Soft int64 `json:"soft"`
+
+	// Hard limit value.
+	Hard int64 `json:"hard"`
+}
+
 // WithDisableAutoCleanup disables auto-removal of this container when the
 // tests complete. Automatic cleanup is a safety net for tests that for some
 // reason fail to run `gnomock.Stop()` in the end, for example due to an
@@ -166,12 +366,56 @@ func WithDisableAutoCleanup() Option {
 }
 
 // WithUseLocalImagesFirst if possible to avoid hitting the Docker Hub pull rate limit.
+//
+// Deprecated: use WithPullPolicy(PullIfNotPresent) instead.
 func WithUseLocalImagesFirst() Option {
 	return func(o *Options) {
 		o.UseLocalImagesFirst = true
 	}
 }
 
+// PullPolicy defines when Gnomock pulls container images.
+type PullPolicy string
+
+// Supported pull policies.
+const (
+	// PullAlways pulls the image before every container is created. This is
+	// the default policy.
+	PullAlways PullPolicy = "always"
+
+	// PullIfNotPresent pulls the image only if it doesn't exist locally.
+	PullIfNotPresent PullPolicy = "if_not_present"
+
+	// PullNever never pulls the image, and fails with ErrImageNotPresent if
+	// it doesn't exist locally. It is useful for offline environments.
+	PullNever PullPolicy = "never"
+)
+
+// WithPullPolicy defines when the container image should be pulled. By
+// default, the image is always pulled.
+func WithPullPolicy(p PullPolicy) Option {
+	return func(o *Options) {
+		o.PullPolicy = p
+	}
+}
+
+// WithPlatform sets the platform of the image to pull and of the container to
+// create, in `os/arch[/variant]` format, for example "linux/arm64". By
+// default, docker daemon platform is used.
+func WithPlatform(platform string) Option {
+	return func(o *Options) {
+		o.Platform = platform
+	}
+}
+
+// WithPullProgressWriter sets the target where to write image pull progress,
+// one update per line.
+func WithPullProgressWriter(w io.Writer) Option {
+	return func(o *Options) {
+		o.pullProgressWriter = w
+	}
+}
+
 // WithCustomNamedPorts allows to define custom ports for a container. This
 // option should be used to override the ports defined by presets.
 func WithCustomNamedPorts(namedPorts NamedPorts) Option {
@@ -221,6 +465,16 @@ func WithCustomImage(image string) Option {
 	}
 }
 
+// WithFaultImage sets the image of the sidecar containers used by InjectFault
+// and ClearFaults on this container. The image must provide `sh` and `tc`
+// from iproute2. Use it to pull the image from a mirror or to pin another
+// version.
+func WithFaultImage(image string) Option {
+	return func(o *Options) {
+		o.faultImage = image
+	}
+}
+
 // WithUser sets the user that the container should run as. It accepts a string
 // value that can be a username/group or UID/GID, in the format accepted by the
 // docker run --user flag (e.g., "1000", "1000:1000", "user:group").
@@ -230,6 +484,49 @@ func WithUser(user string) Option {
 	}
 }
 
+// WithNetwork attaches the container to an existing docker network with the
+// provided name, for example a network created using NewNetwork. Optional
+// aliases become host names of this container inside the network, so that
+// other containers attached to the same network can reach it by name. Use
+// Container.InternalAddress to get such address.
+//
+// This option can be used more than once to attach the container to multiple
+// networks.
+func WithNetwork(name string, aliases ...string) Option {
+	return func(o *Options) {
+		if o.Networks == nil {
+			o.Networks = make(map[string][]string)
+		}
+
+		o.Networks[name] = append(o.Networks[name], aliases...)
+	}
+}
+
+// WithRuntime sets the container runtime used to start and stop the
+// container instead of the default docker engine. See Runtime for the list of
+// features that are not available with custom runtimes. Automatic cleanup is
+// disabled with custom runtimes, so the container must be stopped
+// explicitly.
+func WithRuntime(r Runtime) Option {
+	return func(o *Options) {
+		o.runtime = r
+	}
+}
+
+// WithProxy puts an in-process TCP proxy in front of every TCP port of the
+// container. Container.Address and Container.Port return the addresses of the
+// proxies instead of the ports published by the container, so that the code
+// under test connects through them. Use Container.Proxy to drop or refuse
+// connections, add latency or limit bandwidth of a single port.
+//
+// Proxies run in the current process, and are closed when the container is
+// stopped.
+func WithProxy() Option {
+	return func(o *Options) {
+		o.proxy = true
+	}
+}
+
 // HealthcheckFunc defines a function to be used to determine container health.
 // It receives a host and a port, and returns an error if the container is not
 // ready, or nil when the container can be used. One example of HealthcheckFunc
@@ -284,6 +581,11 @@ type Options struct {
 	// HostMounts allows to mount local paths into the container.
 	HostMounts map[string]string `json:"host_mounts"`
 
+	// Files is a collection of files to copy into the container before it
+	// starts, where every absolute path inside the container points to the
+	// file contents.
+	Files map[string][]byte `json:"files"`
+
 	// DisableAutoCleanup prevents the container from being automatically
 	// stopped and removed after the tests are complete. By default, Gnomock
 	// will try to stop containers created by it right after the tests exit.
@@ -291,8 +593,38 @@ type Options struct {
 
 	// WithUseLocalImagesFirst allows to use existing local images if possible
 	// instead of always pulling the images.
+	//
+	// Deprecated: use PullPolicy instead.
 	UseLocalImagesFirst bool `json:"use_local_images_first"`
 
+	// Labels is a collection of custom labels to set on the container.
+	Labels map[string]string `json:"labels"`
+
+	// MemoryLimit is the maximum amount of memory the container can use, in
+	// bytes.
+	MemoryLimit int64 `json:"memory_limit"`
+
+	// CPUs is the number of CPUs the container can use.
+	CPUs float64 `json:"cpus"`
+
+	// ShmSize is the size of /dev/shm in the container, in bytes.
+	ShmSize int64 `json:"shm_size"`
+
+	// Ulimits is a list of resource limits of processes in the container.
+	Ulimits []Ulimit `json:"ulimits"`
+
+	// Tmpfs is a collection of tmpfs mounts, where every path inside the
+	// container points to mount options, for example "rw,size=512m".
+	Tmpfs map[string]string `json:"tmpfs"`
+
+	// PullPolicy defines when the image should be pulled: "always" (default),
+	// "if_not_present" or "never".
+	PullPolicy PullPolicy `json:"pull_policy"`
+
+	// Platform of the image and the container in `os/arch[/variant]` format,
+	// for example "linux/arm64".
+	Platform string `json:"platform"`
+
 	// CustomNamedPorts allows to override the ports set by the presets. This
 	// option is useful for cases when the presets need to be created with
 	// custom port definitions. This is an advanced feature and should be used
@@ -336,11 +668,34 @@ type Options struct {
 	// This is equivalent to the --user flag in docker run.
 	User string `json:"user"`
 
+	// Networks is a collection of existing docker networks to attach the
+	// container to, where every network name points to a list of aliases of
+	// this container in that network.
+	Networks map[string][]string `json:"networks"`
+
 	ctx                 context.Context
 	init                InitFunc
 	healthcheck         HealthcheckFunc
 	healthcheckInterval time.Duration
 	logWriter           io.Writer
+	pullProgressWriter  io.Writer
+	eventHandler        EventHandler
+	runtime             Runtime
+	autoRemove          bool
+	shared              bool
+	proxy               bool
+
+	healthcheckMaxInterval time.Duration
+	healthcheckJitter      float64
+	dockerHealthcheck      bool
+	dockerHealthcheckCmd   []string
+
+	filesFrom  map[string]string
+	presetName string
+	faultImage string
+
+	waitForLog            *regexp.Regexp
+	waitForLogOccurrences int
 }
 
 func buildConfig(opts ...Option) *Options {
@@ -359,3 +714,42 @@ func buildConfig(opts ...Option) *Options {
 
 	return config
 }
+
+// networkNames returns the names of the networks to attach the container to,
+// in a stable order.
+func (o *Options) networkNames() []string {
+	return sortedKeys(o.Networks)
+}
+
+// pullPolicy returns the configured pull policy, taking deprecated
+// UseLocalImagesFirst flag into account.
+func (o *Options) pullPolicy() PullPolicy {
+	switch {
+	case o.PullPolicy != "":
+		return o.PullPolicy
+	case o.UseLocalImagesFirst:
+		return PullIfNotPresent
+	default:
+		return PullAlways
+	}
+}
+
+// platform parses the configured platform. It returns nil if no platform is
+// set.
+func (o *Options) platform() (*ocispec.Platform, error) {
+	if o.Platform == "" {
+		return nil, nil
+	}
+
+	parts := strings.Split(o.Platform, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid platform '%s', expected os/arch[/variant]", o.Platform)
+	}
+
+	p := &ocispec.Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+
+	return p, nil
+}
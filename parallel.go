@@ -1,6 +1,15 @@
 package gnomock
 
-import "golang.org/x/sync/errgroup"
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+var envNameRegexp = regexp.MustCompile(`[^A-Z0-9]+`)
 
 // InParallel begins parallel preset execution setup. Use Start to add more
 // presets with their configuration to parallel execution, and Go() in the end
@@ -13,42 +22,169 @@ type configuredPreset struct {
 	Preset
 
 	opts []Option
+
+	// indexes of presets this preset depends on, and a function to
+	// configure this preset using the started dependencies
+	deps    []int
+	depOpts func([]*Container) []Option
 }
 
 // Parallel is a builder object that configures parallel preset execution.
 type Parallel struct {
 	presets []configuredPreset
+	err     error
 }
 
 // Start adds the provided preset with its configuration to the parallel
 // execution kicked-off by Go(), together with other added presets.
 func (b *Parallel) Start(p Preset, opts ...Option) *Parallel {
-	b.presets = append(b.presets, configuredPreset{p, opts})
+	b.presets = append(b.presets, configuredPreset{Preset: p, opts: opts})
+
+	return b
+}
+
+// StartAfter adds the provided preset to the parallel execution, but starts
+// it only after all of its dependencies are ready. Dependencies are the
+// indexes of presets added before, using Start or StartAfter, in the order
+// they were added, which is also the order of the containers returned by Go.
+// Presets that don't depend on each other still start in parallel.
+//
+// Dependencies attached to a docker network pass their internal addresses to
+// the new container as environment variables named after the preset package
+// and the port, for example POSTGRES_DEFAULT_ADDR. If several dependencies
+// use the same preset, the following ones get a number after the package
+// name, for example POSTGRES_2_DEFAULT_ADDR. These addresses are only
+// reachable if the new container is attached to the same network, for
+// example using WithNetwork. The provided function, if any, receives the
+// started dependencies in the same order, and returns additional options for
+// the new container.
+func (b *Parallel) StartAfter(p Preset, deps []int, f func(deps []*Container) []Option) *Parallel {
+	for _, i := range deps {
+		if (i < 0 || i >= len(b.presets)) && b.err == nil {
+			b.err = fmt.Errorf("dependency %d of %T must be added before it", i, p)
+		}
+	}
+
+	b.presets = append(b.presets, configuredPreset{Preset: p, deps: deps, depOpts: f})
 
 	return b
 }
 
 // Go kicks-off parallel preset execution. Returned containers are in the same
 // order as they were added with Start. An error is returned if any of the
-// containers failed to start and become available. Even if Go() returns an
-// errors, there still might be containers created in the process, and it is
-// callers responsibility to Stop them.
+// containers failed to start and become available. In this case, containers
+// that already started are stopped, and presets that depend on the failed
+// ones are not started at all.
 func (b *Parallel) Go() ([]*Container, error) {
-	var g errgroup.Group
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	g, ctx := errgroup.WithContext(context.Background())
 
 	containers := make([]*Container, len(b.presets))
+	ready := make([]chan struct{}, len(b.presets))
+
+	for i := range ready {
+		ready[i] = make(chan struct{})
+	}
 
 	for i, preset := range b.presets {
 		containerIndex := i
 		p := preset
 
 		g.Go(func() error {
-			c, err := Start(p.Preset, p.opts...)
+			defer close(ready[containerIndex])
+
+			deps := make([]*Container, 0, len(p.deps))
+
+			for _, dep := range p.deps {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ready[dep]:
+				}
+
+				if containers[dep] == nil {
+					return nil
+				}
+
+				deps = append(deps, containers[dep])
+			}
+
+			c, err := Start(p.Preset, b.options(p, deps)...)
 			containers[containerIndex] = c
 
 			return err
 		})
 	}
 
-	return containers, g.Wait()
+	if err := g.Wait(); err != nil {
+		_ = Stop(containers...)
+		return nil, err
+	}
+
+	return containers, nil
+}
+
+// options returns the options of the provided preset, including the options
+// based on its started dependencies.
+func (b *Parallel) options(p configuredPreset, deps []*Container) []Option {
+	if len(p.deps) == 0 && p.depOpts == nil {
+		return p.opts
+	}
+
+	opts := make([]Option, 0, len(p.opts))
+	opts = append(opts, p.opts...)
+
+	prefixes := make(map[string]int, len(deps))
+
+	for i, dep := range deps {
+		prefix := dependencyPrefix(b.presets[p.deps[i]].Preset)
+
+		prefixes[prefix]++
+		if n := prefixes[prefix]; n > 1 {
+			prefix = fmt.Sprintf("%s_%d", prefix, n)
+		}
+
+		opts = append(opts, dependencyEnv(prefix, dep)...)
+	}
+
+	if p.depOpts != nil {
+		opts = append(opts, p.depOpts(deps)...)
+	}
+
+	return opts
+}
+
+// dependencyPrefix returns the prefix of environment variables with the
+// addresses of the provided dependency.
+func dependencyPrefix(dep Preset) string {
+	if p, ok := dep.(*envPreset); ok {
+		return p.name
+	}
+
+	prefix, _, _ := strings.Cut(presetName(dep), ".")
+
+	return prefix
+}
+
+// dependencyEnv returns options that set environment variables with the
+// internal addresses of every port of the provided container. Containers that
+// are not attached to a network have no addresses reachable from other
+// containers, so no variables are set for them.
+func dependencyEnv(prefix string, c *Container) []Option {
+	opts := make([]Option, 0, len(c.Ports))
+
+	for _, name := range sortedKeys(c.Ports) {
+		addr := c.InternalAddress(name)
+		if addr == "" {
+			continue
+		}
+
+		env := envNameRegexp.ReplaceAllString(strings.ToUpper(prefix+"_"+name+"_addr"), "_")
+		opts = append(opts, WithEnv(env+"="+addr))
+	}
+
+	return opts
 }
@@ -46,6 +46,7 @@ type Message struct {
 
 func init() {
 	registry.Register("kafka", func() gnomock.Preset { return &P{} })
+	registry.RegisterAction("kafka", "messages", messagesAction)
 }
 
 // Preset creates a new Gmomock Kafka preset. This preset includes a
@@ -302,6 +303,23 @@ func (p *P) loadMessagesFromFile(fName string) (msgs []Message, err error) {
 	return msgs, nil
 }
 
+// messagesRequest is a request of "messages" action, which sends more
+// messages to a running container. Missing topics are created.
+type messagesRequest struct {
+	Messages      []Message `json:"messages"`
+	MessagesFiles []string  `json:"messages_files"`
+}
+
+func messagesAction(ctx context.Context, c *gnomock.Container, _ gnomock.Preset, req messagesRequest) error {
+	p := &P{Messages: req.Messages, MessagesFiles: req.MessagesFiles}
+
+	if err := p.initf(ctx, c); err != nil {
+		return fmt.Errorf("can't send messages: %w", err)
+	}
+
+	return nil
+}
+
 func (p *P) connect(c *gnomock.Container) (*kafka.Conn, error) {
 	return kafka.Dial("tcp", c.Address(BrokerPort))
 }
@@ -44,6 +44,16 @@ func WithVersion(version string) Option {
 	}
 }
 
+// WithDataDir sets the directory where postgres stores its data, using PGDATA
+// environment variable. The default data directory is a volume, so it is not
+// included in container snapshots. Use a directory outside of it, for example
+// "/pgdata", to make gnomock.Container.Snapshot work with this preset.
+func WithDataDir(dir string) Option {
+	return func(p *P) {
+		p.DataDir = dir
+	}
+}
+
 // WithTimezone sets the timezone in this container.
 func WithTimezone(timezone string) Option {
 	return func(p *P) {
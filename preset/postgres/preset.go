@@ -24,6 +24,7 @@ const (
 
 func init() {
 	registry.Register("postgres", func() gnomock.Preset { return &P{} })
+	registry.RegisterAction("postgres", "queries", queriesAction)
 }
 
 // Preset creates a new Gmomock Postgres preset. This preset includes a Postgres
@@ -50,6 +51,7 @@ type P struct {
 	User         string   `json:"user"`
 	Password     string   `json:"password"`
 	Timezone     string   `json:"timezone"`
+	DataDir      string   `json:"data_dir"`
 	Version      string   `json:"version"`
 }
 
@@ -87,6 +89,10 @@ func (p *P) Options() []gnomock.Option {
 		opts = append(opts, gnomock.WithEnv("TZ="+p.Timezone))
 	}
 
+	if p.DataDir != "" {
+		opts = append(opts, gnomock.WithEnv("PGDATA="+p.DataDir))
+	}
+
 	return opts
 }
 
@@ -175,6 +181,38 @@ func (p *P) executeQueries(db *sql.DB) error {
 	return nil
 }
 
+// queriesRequest is a request of "queries" action, which executes more
+// queries in a running container.
+type queriesRequest struct {
+	Queries      []string `json:"queries"`
+	QueriesFiles []string `json:"queries_files"`
+}
+
+func queriesAction(_ context.Context, c *gnomock.Container, preset gnomock.Preset, req queriesRequest) error {
+	p, ok := preset.(*P)
+	if !ok {
+		return fmt.Errorf("unexpected preset %T", preset)
+	}
+
+	db, err := connect(c, p.DB)
+	if err != nil {
+		if db != nil {
+			_ = db.Close()
+		}
+
+		return err
+	}
+
+	defer func() { _ = db.Close() }()
+
+	q := &P{Queries: req.Queries, QueriesFiles: req.QueriesFiles}
+	if err := q.executeQueries(db); err != nil {
+		return fmt.Errorf("can't execute queries: %w", err)
+	}
+
+	return nil
+}
+
 func connect(c *gnomock.Container, db string) (*sql.DB, error) {
 	connStr := fmt.Sprintf(
 		"host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
@@ -1,11 +1,14 @@
 package postgres_test
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"testing"
 
+	"github.com/moby/moby/client"
 	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/internal/testutil"
 	"github.com/orlangure/gnomock/preset/postgres"
 	"github.com/stretchr/testify/require"
 
@@ -138,3 +141,53 @@ func TestPreset_withContainerReuseAndDatabase(t *testing.T) {
 
 	t.Cleanup(func() { require.NoError(t, gnomock.Stop(c1, c2)) })
 }
+
+func TestPreset_snapshotWithDataDir(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cli, err := client.New(client.FromEnv)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, testutil.RemoveImage(cli, "gnomock-snapshot:gnomock-postgres-test"))
+		require.NoError(t, cli.Close())
+	})
+
+	p := postgres.Preset(
+		postgres.WithDataDir("/pgdata"),
+		postgres.WithQueries("create table t (a int)", "insert into t values (1)"),
+	)
+
+	c, err := gnomock.Start(p)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { require.NoError(t, gnomock.Stop(c)) })
+	require.NoError(t, c.Snapshot(ctx, "gnomock-postgres-test"))
+
+	count := func() int {
+		connStr := fmt.Sprintf(
+			"host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+			c.Host, c.DefaultPort(), "postgres", "password", "postgres",
+		)
+
+		db, err := sql.Open("postgres", connStr)
+		require.NoError(t, err)
+
+		defer func() { require.NoError(t, db.Close()) }()
+
+		_, err = db.Exec("insert into t values (2)")
+		require.NoError(t, err)
+
+		var n int
+
+		require.NoError(t, db.QueryRow("select count(*) from t").Scan(&n))
+
+		return n
+	}
+
+	require.Equal(t, 2, count())
+	require.NoError(t, c.Restore(ctx, "gnomock-postgres-test"))
+	require.Equal(t, 2, count())
+}
@@ -85,6 +85,25 @@ func (p *P) initf() gnomock.InitFunc {
 	}
 }
 
+// eventsRequest is a request of "events" action, which ingests more events
+// into a running container.
+type eventsRequest struct {
+	Events []Event `json:"events"`
+}
+
+func eventsAction(ctx context.Context, c *gnomock.Container, preset gnomock.Preset, req eventsRequest) error {
+	p, ok := preset.(*P)
+	if !ok {
+		return fmt.Errorf("unexpected preset %T", preset)
+	}
+
+	if err := Ingest(ctx, c, p.AdminPassword, req.Events...); err != nil {
+		return fmt.Errorf("can't ingest events: %w", err)
+	}
+
+	return nil
+}
+
 // Ingest adds the provided events to splunk container. Use the same password
 // you provided in WithPassword. Send as many events as you like, this function
 // only returns when all the events were indexed, or when the context is timed
@@ -33,6 +33,7 @@ const defaultVersion = "latest"
 
 func init() {
 	registry.Register("splunk", func() gnomock.Preset { return &P{} })
+	registry.RegisterAction("splunk", "events", eventsAction)
 }
 
 // Preset creates a new Gnomock Splunk preset. This preset includes a Splunk
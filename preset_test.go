@@ -173,3 +173,84 @@ func TestPreset_customNamedPorts(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 23080, container.Ports.Get("web80").Port)
 }
+
+func TestPreset_parallelDependencies(t *testing.T) {
+	t.Parallel()
+
+	t.Run("starts dependencies first", func(t *testing.T) {
+		rt := newFakeRuntime("")
+		db := &fakePreset{rt: rt}
+		cache := &fakePreset{rt: rt}
+		app := &fakePreset{rt: rt}
+
+		var received []*gnomock.Container
+
+		containers, err := gnomock.InParallel().
+			Start(db).
+			Start(cache).
+			StartAfter(app, []int{0, 1}, func(deps []*gnomock.Container) []gnomock.Option {
+				received = deps
+				return []gnomock.Option{gnomock.WithEnv("APP=1")}
+			}).
+			Go()
+		require.NoError(t, err)
+		require.Len(t, containers, 3)
+		require.Equal(t, containers[:2], received)
+
+		// dependencies that are not attached to a network have no
+		// addresses reachable from other containers
+		require.Equal(t, []string{"APP=1"}, rt.env[containers[2].DockerID()])
+		require.Empty(t, rt.env[containers[0].DockerID()])
+
+		require.NoError(t, gnomock.Stop(containers...))
+		require.Empty(t, rt.containers)
+	})
+
+	t.Run("stops started containers on failure", func(t *testing.T) {
+		rt := newFakeRuntime("")
+		db := &fakePreset{rt: rt}
+		broken := &fakePreset{rt: rt, fail: true}
+		app := &fakePreset{rt: rt}
+
+		containers, err := gnomock.InParallel().
+			Start(db).
+			Start(broken).
+			StartAfter(app, []int{0, 1}, nil).
+			Go()
+		require.Error(t, err)
+		require.Nil(t, containers)
+		require.Empty(t, rt.containers)
+		require.Len(t, rt.env, 2)
+	})
+
+	t.Run("fails with unknown dependency", func(t *testing.T) {
+		rt := newFakeRuntime("")
+
+		_, err := gnomock.InParallel().
+			StartAfter(&fakePreset{rt: rt}, []int{0}, nil).
+			Go()
+		require.Error(t, err)
+		require.Empty(t, rt.env)
+	})
+}
+
+// fakePreset starts containers using a fake runtime.
+type fakePreset struct {
+	rt   *fakeRuntime
+	fail bool
+}
+
+func (p *fakePreset) Image() string             { return "example.com/fake" }
+func (p *fakePreset) Ports() gnomock.NamedPorts { return gnomock.DefaultTCP(80) }
+func (p *fakePreset) Options() []gnomock.Option {
+	opts := []gnomock.Option{gnomock.WithRuntime(p.rt)}
+
+	if p.fail {
+		opts = append(opts,
+			gnomock.WithTimeout(time.Millisecond*500),
+			gnomock.WithHealthCheck(failingHealthcheck),
+		)
+	}
+
+	return opts
+}
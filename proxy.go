@@ -0,0 +1,317 @@
+package gnomock
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	proxyDialTimeout = time.Second * 5
+	proxyBufferSize  = 32 * 1024
+)
+
+// Proxy is an in-process TCP proxy that forwards connections to a single port
+// of a container. Containers started with WithProxy have a proxy in front of
+// every TCP port, and their addresses point to the proxies. Use the proxy to
+// simulate network issues without changing the container itself.
+type Proxy struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu        sync.Mutex
+	closed    bool
+	target    string
+	refuse    bool
+	latency   time.Duration
+	bandwidth int
+	conns     map[net.Conn]struct{}
+}
+
+// Proxy returns the proxy in front of the port with the provided name, or nil
+// if the container was started without WithProxy, or if there is no such TCP
+// port.
+func (c *Container) Proxy(name string) *Proxy {
+	return c.proxies[name]
+}
+
+// DropConnections closes all the active connections going through the proxy.
+// New connections are still accepted.
+func (p *Proxy) DropConnections() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for conn := range p.conns {
+		closeNow(conn)
+		delete(p.conns, conn)
+	}
+}
+
+// RefuseConnections makes the proxy close new connections right after they
+// are accepted, or restores normal behavior if refuse is false. Active
+// connections are not affected.
+func (p *Proxy) RefuseConnections(refuse bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.refuse = refuse
+}
+
+// SetLatency delays every chunk of data going through the proxy in either
+// direction by the provided duration. Zero duration removes the delay.
+func (p *Proxy) SetLatency(d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.latency = d
+}
+
+// SetBandwidth limits the rate of data going through the proxy in each
+// direction of every connection to the provided number of bytes per second.
+// Zero removes the limit.
+func (p *Proxy) SetBandwidth(bytesPerSecond int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.bandwidth = bytesPerSecond
+}
+
+func newProxy(target string) (*Proxy, error) {
+	l, err := net.Listen("tcp", net.JoinHostPort(localhostAddr, "0"))
+	if err != nil {
+		return nil, fmt.Errorf("can't start proxy for %s: %w", target, err)
+	}
+
+	p := &Proxy{
+		listener: l,
+		target:   target,
+		conns:    make(map[net.Conn]struct{}),
+	}
+
+	p.wg.Add(1)
+
+	go p.serve()
+
+	return p, nil
+}
+
+// port returns the port the proxy listens on, or 0 if it is not a TCP port.
+func (p *Proxy) port() int {
+	addr, ok := p.listener.Addr().(*net.TCPAddr)
+	if !ok {
+		return 0
+	}
+
+	return addr.Port
+}
+
+func (p *Proxy) setTarget(target string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.target = target
+}
+
+func (p *Proxy) close() error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	err := p.listener.Close()
+
+	p.DropConnections()
+	p.wg.Wait()
+
+	return err
+}
+
+func (p *Proxy) serve() {
+	defer p.wg.Done()
+
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		p.wg.Add(1)
+
+		go p.handle(conn)
+	}
+}
+
+func (p *Proxy) handle(downstream net.Conn) {
+	defer p.wg.Done()
+
+	p.mu.Lock()
+	target, refuse := p.target, p.refuse
+	p.mu.Unlock()
+
+	if refuse {
+		closeNow(downstream)
+		return
+	}
+
+	upstream, err := net.DialTimeout("tcp", target, proxyDialTimeout)
+	if err != nil {
+		closeNow(downstream)
+		return
+	}
+
+	if !p.track(downstream, upstream) {
+		return
+	}
+
+	defer p.untrack(downstream, upstream)
+
+	done := make(chan struct{}, 2)
+
+	go func() {
+		p.pipe(upstream, downstream)
+		done <- struct{}{}
+	}()
+
+	go func() {
+		p.pipe(downstream, upstream)
+		done <- struct{}{}
+	}()
+
+	// once either side closes the connection, the other side is closed as
+	// well, which stops the second copy
+	<-done
+
+	_, _ = downstream.Close(), upstream.Close()
+
+	<-done
+}
+
+// track registers active connections so that they can be dropped. It returns
+// false and closes the connections if the proxy is already closed.
+func (p *Proxy) track(conns ...net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		for _, conn := range conns {
+			closeNow(conn)
+		}
+
+		return false
+	}
+
+	for _, conn := range conns {
+		p.conns[conn] = struct{}{}
+	}
+
+	return true
+}
+
+func (p *Proxy) untrack(conns ...net.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, conn := range conns {
+		delete(p.conns, conn)
+	}
+}
+
+// pipe copies data from src to dst, applying the configured latency and
+// bandwidth limit to every chunk.
+func (p *Proxy) pipe(dst, src net.Conn) {
+	buf := make([]byte, proxyBufferSize)
+
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			p.mu.Lock()
+			latency, bandwidth := p.latency, p.bandwidth
+			p.mu.Unlock()
+
+			delay := latency
+			if bandwidth > 0 {
+				delay += time.Duration(n) * time.Second / time.Duration(bandwidth)
+			}
+
+			time.Sleep(delay)
+
+			if _, err := dst.Write(buf[:n]); err != nil {
+				return
+			}
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+// closeNow closes the connection without waiting for pending data to be
+// sent, so that the other side receives a reset instead of a regular close.
+func closeNow(conn net.Conn) {
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		_ = tcp.SetLinger(0)
+	}
+
+	_ = conn.Close()
+}
+
+// setupProxies starts a proxy in front of every TCP port of this container,
+// and replaces the addresses of these ports with the addresses of the
+// proxies.
+func (c *Container) setupProxies() error {
+	target := envAwareClone(c)
+	ports := make(NamedPorts, len(c.Ports))
+	c.proxies = make(map[string]*Proxy, len(c.Ports))
+
+	for name, port := range c.Ports {
+		if port.Protocol != "tcp" {
+			ports[name] = port
+			continue
+		}
+
+		p, err := newProxy(target.Address(name))
+		if err != nil {
+			_ = c.closeProxies()
+			return err
+		}
+
+		c.proxies[name] = p
+		port.Port = p.port()
+		ports[name] = port
+	}
+
+	c.Host, c.Ports = localhostAddr, ports
+
+	return nil
+}
+
+// setAddresses updates the addresses of this container after they changed,
+// for example after a restart. Proxies, if any, are pointed to the new
+// addresses, and the container keeps using the same proxy ports.
+func (c *Container) setAddresses(from *Container) {
+	c.gateway = from.gateway
+
+	if len(c.proxies) == 0 {
+		c.Host, c.Ports = from.Host, from.Ports
+		return
+	}
+
+	target := envAwareClone(from)
+	for name, p := range c.proxies {
+		p.setTarget(target.Address(name))
+	}
+}
+
+func (c *Container) closeProxies() error {
+	var errs []error
+
+	for _, p := range c.proxies {
+		errs = append(errs, p.close())
+	}
+
+	c.proxies = nil
+
+	return errors.Join(errs...)
+}
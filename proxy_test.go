@@ -0,0 +1,222 @@
+package gnomock_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orlangure/gnomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithProxy(t *testing.T) {
+	t.Parallel()
+
+	echo := newEchoServer(t)
+	defer echo.close()
+
+	ports := gnomock.NamedPorts{
+		"echo":  gnomock.TCP(echo.port()),
+		"other": {Protocol: "udp", Port: echo.port()},
+	}
+
+	c, err := gnomock.StartCustom(
+		"docker.io/orlangure/gnomock-test-image", ports,
+		gnomock.WithRuntime(newFakeRuntime("")),
+		gnomock.WithProxy(),
+	)
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, gnomock.Stop(c)) }()
+
+	require.NotEqual(t, echo.port(), c.Port("echo"))
+	require.Equal(t, echo.port(), c.Port("other"))
+	require.NotNil(t, c.Proxy("echo"))
+	require.Nil(t, c.Proxy("other"))
+
+	p := c.Proxy("echo")
+
+	t.Run("forwards connections", func(t *testing.T) {
+		conn := dial(t, c.Address("echo"))
+		defer func() { _ = conn.Close() }()
+
+		requireEcho(t, conn, []byte("hello"))
+	})
+
+	t.Run("drops connections", func(t *testing.T) {
+		conn := dial(t, c.Address("echo"))
+		defer func() { _ = conn.Close() }()
+
+		requireEcho(t, conn, []byte("hello"))
+
+		p.DropConnections()
+
+		_, err := conn.Read(make([]byte, 1))
+		require.Error(t, err)
+
+		fresh := dial(t, c.Address("echo"))
+		defer func() { _ = fresh.Close() }()
+
+		requireEcho(t, fresh, []byte("hello"))
+	})
+
+	t.Run("refuses connections", func(t *testing.T) {
+		p.RefuseConnections(true)
+
+		conn := dial(t, c.Address("echo"))
+		defer func() { _ = conn.Close() }()
+
+		_, err := conn.Read(make([]byte, 1))
+		require.Error(t, err)
+
+		p.RefuseConnections(false)
+
+		fresh := dial(t, c.Address("echo"))
+		defer func() { _ = fresh.Close() }()
+
+		requireEcho(t, fresh, []byte("hello"))
+	})
+
+	t.Run("adds latency", func(t *testing.T) {
+		p.SetLatency(time.Millisecond * 200)
+		defer p.SetLatency(0)
+
+		conn := dial(t, c.Address("echo"))
+		defer func() { _ = conn.Close() }()
+
+		start := time.Now()
+
+		requireEcho(t, conn, []byte("hello"))
+		require.GreaterOrEqual(t, time.Since(start), time.Millisecond*400)
+	})
+
+	t.Run("limits bandwidth", func(t *testing.T) {
+		p.SetBandwidth(10000)
+		defer p.SetBandwidth(0)
+
+		conn := dial(t, c.Address("echo"))
+		defer func() { _ = conn.Close() }()
+
+		start := time.Now()
+
+		requireEcho(t, conn, bytes.Repeat([]byte("x"), 2500))
+		require.GreaterOrEqual(t, time.Since(start), time.Millisecond*500)
+	})
+}
+
+func TestWithProxy_stop(t *testing.T) {
+	t.Parallel()
+
+	echo := newEchoServer(t)
+	defer echo.close()
+
+	c, err := gnomock.StartCustom(
+		"docker.io/orlangure/gnomock-test-image", gnomock.DefaultTCP(echo.port()),
+		gnomock.WithRuntime(newFakeRuntime("")),
+		gnomock.WithProxy(),
+	)
+	require.NoError(t, err)
+
+	conn := dial(t, c.DefaultAddress())
+	defer func() { _ = conn.Close() }()
+
+	requireEcho(t, conn, []byte("hello"))
+	require.NoError(t, gnomock.Stop(c))
+
+	_, err = conn.Read(make([]byte, 1))
+	require.Error(t, err)
+
+	_, err = net.DialTimeout("tcp", c.DefaultAddress(), time.Second)
+	require.Error(t, err)
+}
+
+func dial(t *testing.T, addr string) net.Conn {
+	t.Helper()
+
+	conn, err := net.DialTimeout("tcp", addr, time.Second)
+	require.NoError(t, err)
+	require.NoError(t, conn.SetDeadline(time.Now().Add(time.Second*5)))
+
+	return conn
+}
+
+func requireEcho(t *testing.T, conn net.Conn, msg []byte) {
+	t.Helper()
+
+	_, err := conn.Write(msg)
+	require.NoError(t, err)
+
+	buf := make([]byte, len(msg))
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	require.Equal(t, msg, buf)
+}
+
+// echoServer sends back everything it receives.
+type echoServer struct {
+	listener net.Listener
+	wg       sync.WaitGroup
+	mu       sync.Mutex
+	conns    []net.Conn
+}
+
+func newEchoServer(t *testing.T) *echoServer {
+	t.Helper()
+
+	l, err := (&net.ListenConfig{}).Listen(context.Background(), "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &echoServer{listener: l}
+	s.wg.Add(1)
+
+	go func() {
+		defer s.wg.Done()
+
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			s.mu.Lock()
+			s.conns = append(s.conns, conn)
+			s.mu.Unlock()
+
+			s.wg.Add(1)
+
+			go func() {
+				defer s.wg.Done()
+
+				_, _ = io.Copy(conn, conn)
+				_ = conn.Close()
+			}()
+		}
+	}()
+
+	return s
+}
+
+func (s *echoServer) port() int {
+	addr, ok := s.listener.Addr().(*net.TCPAddr)
+	if !ok {
+		return 0
+	}
+
+	return addr.Port
+}
+
+func (s *echoServer) close() {
+	_ = s.listener.Close()
+
+	s.mu.Lock()
+	for _, conn := range s.conns {
+		_ = conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
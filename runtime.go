@@ -0,0 +1,354 @@
+package gnomock
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	cerrdefs "github.com/containerd/errdefs"
+	"github.com/moby/moby/api/types/container"
+)
+
+// Runtime is a container engine that runs Gnomock containers. By default,
+// Gnomock uses docker engine configured using the standard environment
+// variables, such as DOCKER_HOST. Use WithRuntime to replace it with another
+// implementation, for example to work around differences of docker-compatible
+// engines, or with a fake runtime to test presets without a running daemon.
+//
+// Features that talk to docker engine directly, such as automatic cleanup,
+// container reuse, networks, snapshots, Exec, file copy, Pause and Restart,
+// are only available with the default runtime.
+type Runtime interface {
+	// PullImage makes sure the image is available to create containers
+	// from, respecting the pull policy and the platform set in the options.
+	// Pull progress should be reported using ReportPullProgress.
+	PullImage(ctx context.Context, image string, cfg *Options) error
+
+	// CreateContainer creates a new container from the image, exposing the
+	// provided ports, and returns its ID. The container should not be
+	// started yet. Besides the exported fields of the options, it should
+	// respect ContainerLabels, FilesFrom and DockerHealthcheckCmd.
+	CreateContainer(ctx context.Context, image string, ports NamedPorts, cfg *Options) (string, error)
+
+	// StartContainer starts a created container.
+	StartContainer(ctx context.Context, id string) error
+
+	// InspectContainer returns the current state of the container. It may
+	// return fewer bound ports than requested while the container is
+	// starting, and Gnomock keeps inspecting it until all the ports are
+	// bound.
+	InspectContainer(ctx context.Context, id string, ports NamedPorts) (*ContainerState, error)
+
+	// ContainerLogs returns a stream of stdout and stderr of the container
+	// as plain text. The stream should follow the logs until it is closed,
+	// or until the container stops.
+	ContainerLogs(ctx context.Context, id string) (io.ReadCloser, error)
+
+	// StopContainer stops a running container. It should not fail if the
+	// container no longer exists.
+	StopContainer(ctx context.Context, id string) error
+
+	// RemoveContainer removes a container. It should not fail if the
+	// container no longer exists.
+	RemoveContainer(ctx context.Context, id string) error
+}
+
+// ContainerState is the state of a container reported by Runtime.
+type ContainerState struct {
+	// Host is the address of the host where container ports are bound.
+	Host string
+
+	// Ports are the host ports bound to the container ports, using the same
+	// names as the requested ports.
+	Ports NamedPorts
+
+	// Gateway is the address of the default gateway in the container
+	// network. It is used to reach the container when Gnomock itself runs
+	// inside a container.
+	Gateway string
+
+	// Exited is true when the container is no longer running, for example
+	// because its main process exited. ExitCode is the exit code of the main
+	// process in this case, and OOMKilled is true if it was killed because
+	// it ran out of memory.
+	Exited    bool
+	ExitCode  int
+	OOMKilled bool
+
+	// Health is the status reported by the healthcheck defined in the image
+	// or using WithDockerHealthCheck, such as "starting" or "healthy". It is
+	// empty if the container doesn't have such healthcheck.
+	Health string
+}
+
+// ContainerLabels returns the labels to set on a new container, including the
+// labels Gnomock uses to find and clean up its containers.
+func (o *Options) ContainerLabels() map[string]string {
+	return o.labels()
+}
+
+// FilesFrom returns the files and directories set using WithFileFrom, by their
+// path in the container. They should be copied from the machine running
+// Gnomock into the container before it starts.
+func (o *Options) FilesFrom() map[string]string {
+	return maps.Clone(o.filesFrom)
+}
+
+// DockerHealthcheckCmd returns the command set using WithDockerHealthCheck, if
+// any. It should be used as the healthcheck of a new container, so that Health
+// reported by InspectContainer reflects its result.
+func (o *Options) DockerHealthcheckCmd() []string {
+	return slices.Clone(o.dockerHealthcheckCmd)
+}
+
+// ReportPullProgress reports a single image pull progress update, both as an
+// event and to the writer set using WithPullProgressWriter.
+func (o *Options) ReportPullProgress(image, msg string) {
+	o.Emit(Event{Type: EventImagePullProgress, Image: image, Message: msg})
+
+	if o.pullProgressWriter != nil {
+		_, _ = fmt.Fprintln(o.pullProgressWriter, msg)
+	}
+}
+
+// runtime returns the runtime set in the provided options, or connects to
+// docker engine if it is not set. The returned function releases the
+// runtime when it is no longer needed.
+func (g *g) runtime(cfg *Options) (Runtime, func(), error) {
+	if cfg != nil && cfg.runtime != nil {
+		return cfg.runtime, func() {}, nil
+	}
+
+	cli, err := g.dockerConnect()
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't create docker client: %w", err)
+	}
+
+	return cli, func() { _ = cli.stopClient() }, nil
+}
+
+func (g *g) startContainer(
+	ctx context.Context,
+	rt Runtime,
+	image string,
+	ports NamedPorts,
+	cfg *Options,
+) (*Container, error) {
+	d, isDocker := rt.(*docker)
+
+	if cfg.Reuse {
+		if !isDocker {
+			return nil, fmt.Errorf("container reuse is not supported by custom runtime")
+		}
+
+		id, ok, err := d.findReusableContainer(ctx, image, cfg)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			g.log.Info("re-using container")
+
+			container, err := g.waitForContainerNetwork(ctx, rt, id, ports)
+			if err != nil {
+				return nil, err
+			}
+
+			container.reused = true
+
+			return container, d.setupInternalAddress(ctx, container, cfg)
+		}
+	}
+
+	g.log.Info("starting container")
+
+	id, err := g.prepareContainer(ctx, rt, image, ports, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("can't prepare container: %w", err)
+	}
+
+	sidecarChan, cleanupCancel := setupContainerCleanup(id, cfg)
+	start := time.Now()
+
+	// containers that fail to start are kept in debug mode, so that they can
+	// be inspected
+	cleanup := func() {
+		cleanupCancel()
+
+		if !cfg.Debug {
+			_ = rt.RemoveContainer(context.Background(), id)
+		}
+	}
+
+	err = rt.StartContainer(ctx, id)
+	if err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	container, err := g.waitForContainerNetwork(ctx, rt, id, ports)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("container network isn't ready: %w", err)
+	}
+
+	if isDocker {
+		if err := d.setupInternalAddress(ctx, container, cfg); err != nil {
+			cleanup()
+			return nil, fmt.Errorf("can't find container address in network: %w", err)
+		}
+	}
+
+	if sidecar, ok := <-sidecarChan; ok {
+		container.ID = generateID(container.ID, sidecar)
+	}
+
+	g.log.Infow("container started", "container", container)
+	cfg.Emit(Event{
+		Type:        EventContainerStarted,
+		Image:       image,
+		ContainerID: id,
+		Duration:    time.Since(start),
+	})
+
+	return container, nil
+}
+
+func (g *g) prepareContainer(
+	ctx context.Context,
+	rt Runtime,
+	image string,
+	ports NamedPorts,
+	cfg *Options,
+) (string, error) {
+	if err := rt.PullImage(ctx, image, cfg); err != nil {
+		return "", fmt.Errorf("can't pull image: %w", err)
+	}
+
+	start := time.Now()
+
+	id, err := rt.CreateContainer(ctx, image, ports, cfg)
+	if err != nil {
+		return "", fmt.Errorf("can't create container: %w", err)
+	}
+
+	cfg.Emit(Event{
+		Type:        EventContainerCreated,
+		Image:       image,
+		ContainerID: id,
+		Duration:    time.Since(start),
+	})
+
+	return id, nil
+}
+
+func (g *g) waitForContainerNetwork(
+	ctx context.Context,
+	rt Runtime,
+	id string,
+	ports NamedPorts,
+) (*Container, error) {
+	g.log.Infow("waiting for container network", "container", id)
+
+	tick := time.NewTicker(time.Millisecond * 250)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("container network is unavailable after timeout")
+		case <-tick.C:
+			state, err := rt.InspectContainer(ctx, id, ports)
+			if err != nil {
+				if cerrdefs.IsNotFound(err) {
+					return nil, exitError(ctx, rt, id, &ContainerState{ExitCode: -1}, nil)
+				}
+
+				return nil, err
+			}
+
+			if state.Exited {
+				return nil, exitError(ctx, rt, id, state, nil)
+			}
+
+			g.log.Infow("waiting for port allocation", "container", id)
+
+			if len(state.Ports) == len(ports) {
+				return &Container{
+					ID:            id,
+					Host:          state.Host,
+					Ports:         state.Ports,
+					gateway:       state.Gateway,
+					internalPorts: ports,
+				}, nil
+			}
+		}
+	}
+}
+
+// checkContainer returns ErrContainerExited if the container is no longer
+// running, so that waiting for it can stop early. If docker healthcheck is
+// enabled in the provided options, it also returns an error until the
+// container is reported healthy, or ErrNoDockerHealthcheck if there is no
+// docker healthcheck to wait for.
+func (g *g) checkContainer(ctx context.Context, rt Runtime, c *Container, cfg *Options) error {
+	state, err := rt.InspectContainer(ctx, c.DockerID(), c.internalPorts)
+	if err != nil {
+		// the container was removed from the outside, for example by the
+		// cleaner, so the exit code is no longer available
+		if cerrdefs.IsNotFound(err) {
+			return exitError(ctx, rt, c.DockerID(), &ContainerState{ExitCode: -1}, c.logTail)
+		}
+
+		return err
+	}
+
+	if state.Exited {
+		return exitError(ctx, rt, c.DockerID(), state, c.logTail)
+	}
+
+	if cfg != nil && cfg.dockerHealthcheck {
+		switch state.Health {
+		case string(container.Healthy):
+		case "", string(container.NoHealthcheck):
+			return ErrNoDockerHealthcheck
+		default:
+			return fmt.Errorf("docker healthcheck status is %q", state.Health)
+		}
+	}
+
+	return nil
+}
+
+// exitError returns ContainerExitError for a container that exited. The last
+// log lines are taken from the provided tail of forwarded logs if it is set,
+// or read from the runtime otherwise.
+func exitError(ctx context.Context, rt Runtime, id string, state *ContainerState, tail *logTail) error {
+	ctx, cancel := context.WithTimeout(ctx, exitLogsTimeout)
+	defer cancel()
+
+	if tail == nil {
+		tail = newLogTail(exitLogLines)
+
+		if logs, err := rt.ContainerLogs(ctx, id); err == nil {
+			_, _ = io.Copy(tail, logs)
+			_ = logs.Close()
+		}
+	} else {
+		// the log stream ends once the container exits, but the last lines
+		// may still be on their way
+		select {
+		case <-tail.done:
+		case <-ctx.Done():
+		}
+	}
+
+	return &ContainerExitError{
+		ExitCode:  state.ExitCode,
+		OOMKilled: state.OOMKilled,
+		Logs:      tail.lines(),
+	}
+}
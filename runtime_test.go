@@ -0,0 +1,314 @@
+package gnomock_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/orlangure/gnomock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRuntime keeps containers in memory, and binds every container port to
+// the same port number on the host.
+type fakeRuntime struct {
+	mu         sync.Mutex
+	containers map[string]string
+	env        map[string][]string
+	options    map[string]*gnomock.Options
+	pulled     []string
+	logs       string
+
+	// state reported for every container
+	exited   bool
+	exitCode int
+	health   string
+}
+
+func newFakeRuntime(logs string) *fakeRuntime {
+	return &fakeRuntime{
+		containers: make(map[string]string),
+		env:        make(map[string][]string),
+		options:    make(map[string]*gnomock.Options),
+		logs:       logs,
+	}
+}
+
+func (r *fakeRuntime) PullImage(_ context.Context, image string, cfg *gnomock.Options) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.pulled = append(r.pulled, image)
+	cfg.ReportPullProgress(image, "pulled")
+
+	return nil
+}
+
+func (r *fakeRuntime) CreateContainer(
+	_ context.Context, _ string, _ gnomock.NamedPorts, cfg *gnomock.Options,
+) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := fmt.Sprintf("fake%d", len(r.env))
+	r.containers[id] = "created"
+	r.env[id] = cfg.Env
+	r.options[id] = cfg
+
+	return id, nil
+}
+
+func (r *fakeRuntime) StartContainer(_ context.Context, id string) error {
+	return r.setState(id, "created", "running")
+}
+
+func (r *fakeRuntime) InspectContainer(
+	_ context.Context, id string, ports gnomock.NamedPorts,
+) (*gnomock.ContainerState, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.containers[id]; !ok {
+		return nil, fmt.Errorf("no such container: %s", id)
+	}
+
+	return &gnomock.ContainerState{
+		Host:     "127.0.0.1",
+		Ports:    ports,
+		Gateway:  "172.17.0.1",
+		Exited:   r.exited,
+		ExitCode: r.exitCode,
+		Health:   r.health,
+	}, nil
+}
+
+func (r *fakeRuntime) ContainerLogs(context.Context, string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader(r.logs)), nil
+}
+
+func (r *fakeRuntime) StopContainer(_ context.Context, id string) error {
+	return r.setState(id, "running", "stopped")
+}
+
+func (r *fakeRuntime) RemoveContainer(_ context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.containers, id)
+
+	return nil
+}
+
+func (r *fakeRuntime) setHealth(health string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.health = health
+}
+
+func (r *fakeRuntime) setState(id, from, to string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if state := r.containers[id]; state != from {
+		return fmt.Errorf("container %s is %s, expected %s", id, state, from)
+	}
+
+	r.containers[id] = to
+
+	return nil
+}
+
+func TestRuntime(t *testing.T) {
+	t.Parallel()
+
+	rt := newFakeRuntime("starting\nready to accept connections\n")
+	namedPorts := gnomock.NamedPorts{
+		"web80":   gnomock.TCP(80),
+		"web8080": gnomock.TCP(8080),
+	}
+
+	var healthchecks int
+
+	c, err := gnomock.StartCustom(
+		"example.com/fake", namedPorts,
+		gnomock.WithRuntime(rt),
+		gnomock.WithWaitForLog(regexp.MustCompile("ready"), 1),
+		gnomock.WithHealthCheck(func(_ context.Context, c *gnomock.Container) error {
+			healthchecks++
+
+			if c.Address("web80") != "127.0.0.1:80" {
+				return fmt.Errorf("unexpected address: %s", c.Address("web80"))
+			}
+
+			return nil
+		}),
+	)
+	require.NoError(t, err)
+	require.Equal(t, "127.0.0.1", c.Host)
+	require.Equal(t, namedPorts, c.Ports)
+	require.Equal(t, 1, healthchecks)
+	require.Equal(t, []string{"example.com/fake:latest"}, rt.pulled)
+	require.Equal(t, map[string]string{c.DockerID(): "running"}, rt.containers)
+
+	require.NoError(t, gnomock.Stop(c))
+	require.Empty(t, rt.containers)
+
+	t.Run("reuse is not supported", func(t *testing.T) {
+		_, err := gnomock.StartCustom(
+			"example.com/fake", namedPorts,
+			gnomock.WithRuntime(rt),
+			gnomock.WithContainerReuse(),
+			gnomock.WithContainerName("fake"),
+		)
+		require.Error(t, err)
+		require.Empty(t, rt.containers)
+	})
+}
+
+func TestRuntime_options(t *testing.T) {
+	t.Parallel()
+
+	rt := newFakeRuntime("")
+	rt.health = "healthy"
+
+	var (
+		progress bytes.Buffer
+		events   []gnomock.EventType
+	)
+
+	c, err := gnomock.StartCustom(
+		"example.com/fake", gnomock.DefaultTCP(80),
+		gnomock.WithRuntime(rt),
+		gnomock.WithFileFrom("./testdata", "/data"),
+		gnomock.WithDockerHealthCheck("true"),
+		gnomock.WithPullProgressWriter(&progress),
+		gnomock.WithEventHandler(func(e gnomock.Event) { events = append(events, e.Type) }),
+	)
+	require.NoError(t, err)
+	require.NoError(t, gnomock.Stop(c))
+
+	cfg := rt.options[c.DockerID()]
+	require.Equal(t, map[string]string{"/data": "./testdata"}, cfg.FilesFrom())
+	require.Equal(t, []string{"true"}, cfg.DockerHealthcheckCmd())
+	require.Contains(t, cfg.ContainerLabels(), gnomock.LabelSession)
+	require.Equal(t, "pulled\n", progress.String())
+	require.Contains(t, events, gnomock.EventImagePullProgress)
+}
+
+func TestRuntime_containerExit(t *testing.T) {
+	t.Parallel()
+
+	rt := newFakeRuntime("starting\ninvalid license\n")
+	rt.exited, rt.exitCode = true, 3
+
+	start := time.Now()
+
+	c, err := gnomock.StartCustom(
+		"example.com/fake", gnomock.DefaultTCP(80),
+		gnomock.WithRuntime(rt),
+		gnomock.WithTimeout(time.Minute),
+	)
+	require.ErrorIs(t, err, gnomock.ErrContainerExited)
+	require.Nil(t, c)
+	require.Less(t, time.Since(start), time.Second*10)
+
+	var exitErr *gnomock.ContainerExitError
+
+	require.ErrorAs(t, err, &exitErr)
+	require.Equal(t, 3, exitErr.ExitCode)
+	require.False(t, exitErr.OOMKilled)
+	require.Equal(t, []string{"starting", "invalid license"}, exitErr.Logs)
+	require.Empty(t, rt.containers)
+}
+
+func TestRuntime_health(t *testing.T) {
+	t.Parallel()
+
+	rt := newFakeRuntime("")
+
+	var healthy bool
+
+	c, err := gnomock.StartCustom(
+		"example.com/fake", gnomock.DefaultTCP(80),
+		gnomock.WithRuntime(rt),
+		gnomock.WithHealthCheck(func(context.Context, *gnomock.Container) error {
+			if healthy {
+				return nil
+			}
+
+			healthy = true
+
+			return fmt.Errorf("starting")
+		}),
+	)
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, gnomock.Stop(c)) }()
+
+	ctx := context.Background()
+
+	require.NoError(t, c.Health(ctx))
+
+	healthy = false
+	require.EqualError(t, c.Health(ctx), "starting")
+
+	rt.mu.Lock()
+	rt.exited = true
+	rt.mu.Unlock()
+
+	require.ErrorIs(t, c.Health(ctx), gnomock.ErrContainerExited)
+}
+
+func TestRuntime_dockerHealthCheck(t *testing.T) {
+	t.Parallel()
+
+	rt := newFakeRuntime("")
+	rt.health = "starting"
+
+	t.Run("unhealthy", func(t *testing.T) {
+		c, err := gnomock.StartCustom(
+			"example.com/fake", gnomock.DefaultTCP(80),
+			gnomock.WithRuntime(rt),
+			gnomock.WithDockerHealthCheck(),
+			gnomock.WithTimeout(time.Second),
+		)
+		require.ErrorContains(t, err, `docker healthcheck status is "starting"`)
+		require.Nil(t, c)
+	})
+
+	t.Run("no healthcheck", func(t *testing.T) {
+		rt := newFakeRuntime("")
+
+		start := time.Now()
+		c, err := gnomock.StartCustom(
+			"example.com/fake", gnomock.DefaultTCP(80),
+			gnomock.WithRuntime(rt),
+			gnomock.WithDockerHealthCheck(),
+			gnomock.WithTimeout(time.Second*10),
+		)
+		require.ErrorIs(t, err, gnomock.ErrNoDockerHealthcheck)
+		require.Nil(t, c)
+		require.Less(t, time.Since(start), time.Second*5)
+	})
+
+	t.Run("healthy", func(t *testing.T) {
+		time.AfterFunc(time.Millisecond*500, func() { rt.setHealth("healthy") })
+
+		c, err := gnomock.StartCustom(
+			"example.com/fake", gnomock.DefaultTCP(80),
+			gnomock.WithRuntime(rt),
+			gnomock.WithDockerHealthCheck(),
+			gnomock.WithHealthCheckBackoff(time.Millisecond*10, time.Millisecond*100, 0.1),
+			gnomock.WithTimeout(time.Second*5),
+		)
+		require.NoError(t, err)
+		require.NoError(t, gnomock.Stop(c))
+	})
+}
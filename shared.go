@@ -0,0 +1,270 @@
+package gnomock
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const (
+	sharedPrefix          = "gnomock-shared-"
+	sharedLockStaleAfter  = defaultTimeout
+	sharedLockRetryPeriod = time.Millisecond * 50
+)
+
+// Shared creates a container using the provided Preset, similar to Start, but
+// shares it with other processes that request the same container. It is
+// useful with `go test ./...`, where every package runs in a separate process
+// and would otherwise start its own container.
+//
+// Containers are identified by the image, the configuration of the preset and
+// the provided options: the same preset with the same configuration and
+// options always uses the same container. The initial state is only set up
+// when the container is created. Every call to Shared must be followed by a
+// call to Release, usually at the end of TestMain. The container is stopped
+// when the last process using it releases it.
+//
+// Every user of a shared container is tracked by its process ID, so processes
+// that exit without releasing the container, for example because they
+// crashed, are no longer counted as users. Shared containers are started with
+// container reuse enabled, so such containers are not removed right away, but
+// are reused by the following calls to Shared, and stopped once released.
+func Shared(p Preset, opts ...Option) (*Container, error) {
+	config := buildConfig(opts...)
+
+	image := buildImage(p.Image())
+	if config.CustomImage != "" {
+		image = config.CustomImage
+	}
+
+	name, err := sharedName(p, image, config)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(config.ctx, config.Timeout)
+	defer cancel()
+
+	unlock, err := lockShared(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	defer unlock()
+
+	opts = append(opts, WithContainerReuse(), WithContainerName(name), func(o *Options) { o.shared = true })
+
+	c, err := Start(p, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := acquireShared(name, c.DockerID()); err != nil {
+		return nil, err
+	}
+
+	c.shared = name
+
+	return c, nil
+}
+
+// Release releases the provided containers created using Shared. Containers
+// that are no longer used by any process are stopped. Release returns an
+// error if any one of the containers couldn't be released.
+func Release(cs ...*Container) error {
+	for _, c := range cs {
+		if c == nil || c.shared == "" {
+			continue
+		}
+
+		if err := release(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func release(c *Container) error {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	unlock, err := lockShared(ctx, c.shared)
+	if err != nil {
+		return err
+	}
+
+	defer unlock()
+
+	last, err := releaseShared(c.shared)
+	if err != nil {
+		return err
+	}
+
+	if !last {
+		return nil
+	}
+
+	return Stop(c)
+}
+
+// sharedName returns a container name that depends only on the image, the
+// configuration of the provided preset and the provided options.
+func sharedName(p Preset, image string, config *Options) (string, error) {
+	bs, err := json.Marshal(p)
+	if err != nil {
+		return "", fmt.Errorf("can't encode preset: %w", err)
+	}
+
+	opts, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("can't encode options: %w", err)
+	}
+
+	h := sha256.New()
+	_, _ = h.Write([]byte(image))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(bs)
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(opts)
+
+	return sharedPrefix + hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// sharedState is the state of a shared container, stored in a file to be
+// available to all the processes on the host. Users are the process IDs of
+// the processes using the container, once per call to Shared.
+type sharedState struct {
+	ContainerID string `json:"container_id"`
+	Users       []int  `json:"users"`
+}
+
+// pruneUsers forgets the users whose processes no longer run.
+func (s *sharedState) pruneUsers() {
+	s.Users = slices.DeleteFunc(s.Users, func(pid int) bool { return !processRunning(pid) })
+}
+
+func sharedPath(name string) string {
+	return filepath.Join(os.TempDir(), name+".json")
+}
+
+func readSharedState(name string) (*sharedState, error) {
+	state := &sharedState{}
+
+	bs, err := os.ReadFile(sharedPath(name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("can't read shared container state: %w", err)
+	}
+
+	if err := json.Unmarshal(bs, state); err != nil {
+		return nil, fmt.Errorf("can't decode shared container state: %w", err)
+	}
+
+	return state, nil
+}
+
+func writeSharedState(name string, state *sharedState) error {
+	bs, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("can't encode shared container state: %w", err)
+	}
+
+	if err := os.WriteFile(sharedPath(name), bs, 0o600); err != nil {
+		return fmt.Errorf("can't write shared container state: %w", err)
+	}
+
+	return nil
+}
+
+// acquireShared registers a new user of the shared container with the
+// provided name. If the container was replaced, for example after it was
+// removed manually, users of the previous container are forgotten. It must be
+// called while holding the lock.
+func acquireShared(name, id string) error {
+	state, err := readSharedState(name)
+	if err != nil {
+		return err
+	}
+
+	if state.ContainerID != id {
+		state = &sharedState{ContainerID: id}
+	}
+
+	state.pruneUsers()
+	state.Users = append(state.Users, os.Getpid())
+
+	return writeSharedState(name, state)
+}
+
+// releaseShared unregisters a user of the shared container with the provided
+// name, and reports whether it was the last one, ignoring the users that
+// exited without releasing the container. It must be called while holding the
+// lock.
+func releaseShared(name string) (bool, error) {
+	state, err := readSharedState(name)
+	if err != nil {
+		return false, err
+	}
+
+	if i := slices.Index(state.Users, os.Getpid()); i >= 0 {
+		state.Users = slices.Delete(state.Users, i, i+1)
+	}
+
+	state.pruneUsers()
+
+	if len(state.Users) > 0 {
+		return false, writeSharedState(name, state)
+	}
+
+	err = os.Remove(sharedPath(name))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, fmt.Errorf("can't remove shared container state: %w", err)
+	}
+
+	return true, nil
+}
+
+// lockShared acquires an exclusive lock on the shared container with the
+// provided name, which works across processes. Locks that are held for too
+// long are considered abandoned and are taken over.
+func lockShared(ctx context.Context, name string) (func(), error) {
+	path := filepath.Join(os.TempDir(), name+".lock")
+
+	tick := time.NewTicker(sharedLockRetryPeriod)
+	defer tick.Stop()
+
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600) // nolint:gosec
+		if err == nil {
+			_ = f.Close()
+			return func() { _ = os.Remove(path) }, nil
+		}
+
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("can't lock shared container: %w", err)
+		}
+
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > sharedLockStaleAfter {
+			_ = os.Remove(path)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("can't lock shared container: %w", ctx.Err())
+		case <-tick.C:
+		}
+	}
+}
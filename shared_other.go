@@ -0,0 +1,18 @@
+//go:build !unix
+
+package gnomock
+
+import "os"
+
+// processRunning reports whether a process with the provided ID is running.
+// On Windows, a process can only be found while it is running.
+func processRunning(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+
+	_ = p.Release()
+
+	return true
+}
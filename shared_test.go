@@ -0,0 +1,55 @@
+package gnomock_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/moby/moby/client"
+	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShared(t *testing.T) {
+	t.Parallel()
+
+	p := &testutil.TestPreset{Img: testutil.TestImage}
+
+	// initial state is only set up once, when the container is created
+	inits := 0
+	initf := gnomock.WithInit(func(context.Context, *gnomock.Container) error {
+		inits++
+		if inits > 1 {
+			return fmt.Errorf("already initialized")
+		}
+
+		return nil
+	})
+
+	first, err := gnomock.Shared(p, initf)
+	require.NoError(t, err)
+
+	second, err := gnomock.Shared(p, initf)
+	require.NoError(t, err)
+	require.Equal(t, first.DockerID(), second.DockerID())
+	require.Equal(t, first.Ports, second.Ports)
+	require.Equal(t, 1, inits)
+
+	cli, err := client.New(client.FromEnv)
+	require.NoError(t, err)
+
+	defer func() { require.NoError(t, cli.Close()) }()
+
+	require.NoError(t, gnomock.Release(first))
+
+	containerList, err := testutil.ListContainerByID(cli, second.DockerID())
+	require.NoError(t, err)
+	require.Len(t, containerList, 1)
+
+	require.NoError(t, gnomock.Release(second))
+
+	containerList, err = testutil.ListContainerByID(cli, second.DockerID())
+	require.NoError(t, err)
+	require.Len(t, containerList, 0)
+}
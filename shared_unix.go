@@ -0,0 +1,15 @@
+//go:build unix
+
+package gnomock
+
+import (
+	"errors"
+	"syscall"
+)
+
+// processRunning reports whether a process with the provided ID is running.
+func processRunning(pid int) bool {
+	err := syscall.Kill(pid, 0)
+
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
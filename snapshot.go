@@ -0,0 +1,102 @@
+package gnomock
+
+import (
+	"context"
+	"fmt"
+	"maps"
+)
+
+const (
+	snapshotRepository = "gnomock-snapshot"
+	snapshotPortsLabel = "gnomock.ports"
+)
+
+// Snapshot saves the current state of this container, including its file
+// system, as a local image with the provided name. Use Restore or
+// StartFromSnapshot to create new containers from this image. Snapshot name
+// must be a valid docker image tag, for example "seeded-db". Existing
+// snapshots with the same name are replaced.
+//
+// Note that data stored in volumes is not included in the snapshot. Some
+// images, like postgres, declare their data directory as a volume, so a
+// custom data directory is required for the snapshot to include it, for
+// example using postgres.WithDataDir.
+func (c *Container) Snapshot(ctx context.Context, name string) error {
+	return withDocker(func(cli *docker) error {
+		return cli.commitContainer(ctx, c.DockerID(), snapshotImage(name), c.internalPorts)
+	})
+}
+
+// Restore replaces this container with a fresh container created from the
+// snapshot with the provided name. The new container keeps the configuration
+// of the original one, but host ports that were not fixed using HostPort are
+// allocated again, so Ports and addresses should be read again after Restore.
+// The healthcheck runs again, but InitFunc does not.
+//
+// Restore is only available for containers started by the current process,
+// and is not available for containers created using Shared, since they are
+// used by other processes as well.
+func (c *Container) Restore(ctx context.Context, name string) error {
+	if c.config == nil {
+		return fmt.Errorf("can't restore container %s: configuration unknown", c.ID)
+	}
+
+	if c.shared != "" {
+		return fmt.Errorf("can't restore shared container %s", c.ID)
+	}
+
+	// the ports are requested the same way as for the original container, so
+	// fixed host ports are kept, and other ports are allocated again
+	ports := maps.Clone(c.internalPorts)
+
+	config := *c.config
+	config.ctx = ctx
+	config.init = nopInit
+	config.PullPolicy = PullNever
+
+	if err := Stop(c); err != nil {
+		return fmt.Errorf("can't stop container: %w", err)
+	}
+
+	g, err := newG(config.Debug)
+	if err != nil {
+		return fmt.Errorf("can't create new gnomock session: %w", err)
+	}
+
+	defer func() { _ = g.log.Sync() }()
+
+	restored, err := newContainer(g, snapshotImage(name), ports, &config)
+	if err != nil {
+		return fmt.Errorf("can't restore snapshot %s: %w", name, err)
+	}
+
+	*c = *restored
+
+	return nil
+}
+
+// StartFromSnapshot creates a new container from the snapshot with the
+// provided name. Named ports of the original container are used unless
+// WithCustomNamedPorts is provided. Other options, like healthcheck, should be
+// provided again.
+func StartFromSnapshot(name string, opts ...Option) (*Container, error) {
+	image := snapshotImage(name)
+
+	var ports NamedPorts
+
+	err := withDocker(func(cli *docker) (err error) {
+		ports, err = cli.snapshotPorts(buildConfig(opts...).ctx, image)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't find snapshot %s: %w", name, err)
+	}
+
+	opts = append([]Option{WithPullPolicy(PullNever)}, opts...)
+
+	return StartCustom(image, ports, opts...)
+}
+
+func snapshotImage(name string) string {
+	return fmt.Sprintf("%s:%s", snapshotRepository, name)
+}
@@ -0,0 +1,81 @@
+package gnomock_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moby/moby/client"
+	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainer_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cli, err := client.New(client.FromEnv)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, testutil.RemoveImage(cli, "gnomock-snapshot:gnomock-test"))
+		require.NoError(t, cli.Close())
+	})
+
+	container, err := gnomock.StartCustom(
+		"docker.io/library/busybox:1.35.0",
+		gnomock.DefaultTCP(testutil.GoodPort80),
+		gnomock.WithCommand("sleep", "60"),
+	)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, gnomock.Stop(container))
+	}()
+
+	_, _, code, err := container.Exec(ctx, []string{"sh", "-c", "echo seeded > /state"})
+	require.NoError(t, err)
+	require.Equal(t, 0, code)
+
+	require.NoError(t, container.Snapshot(ctx, "gnomock-test"))
+
+	_, _, code, err = container.Exec(ctx, []string{"sh", "-c", "echo dirty > /state"})
+	require.NoError(t, err)
+	require.Equal(t, 0, code)
+
+	image, err := cli.ImageInspect(ctx, "gnomock-snapshot:gnomock-test")
+	require.NoError(t, err)
+	require.Contains(t, image.Config.Labels, "gnomock.ports")
+	require.NotContains(t, image.Config.Labels, gnomock.LabelSession)
+	require.NotContains(t, image.Config.Labels, gnomock.LabelReuse)
+
+	require.NoError(t, container.Restore(ctx, "gnomock-test"))
+	require.NotZero(t, container.DefaultPort())
+
+	stdout, _, _, err := container.Exec(ctx, []string{"cat", "/state"})
+	require.NoError(t, err)
+	require.Equal(t, "seeded\n", string(stdout))
+
+	t.Run("start from snapshot", func(t *testing.T) {
+		c, err := gnomock.StartFromSnapshot("gnomock-test")
+		require.NoError(t, err)
+		require.NotZero(t, c.DefaultPort())
+
+		stdout, _, _, err := c.Exec(ctx, []string{"cat", "/state"})
+		require.NoError(t, err)
+		require.Equal(t, "seeded\n", string(stdout))
+		require.NoError(t, gnomock.Stop(c))
+	})
+
+	t.Run("unknown snapshot", func(t *testing.T) {
+		c, err := gnomock.StartFromSnapshot("gnomock-test-unknown")
+		require.Error(t, err)
+		require.Nil(t, c)
+	})
+
+	t.Run("restore requires known configuration", func(t *testing.T) {
+		c := &gnomock.Container{ID: container.ID}
+		require.Error(t, c.Restore(ctx, "gnomock-test"))
+	})
+}
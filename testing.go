@@ -0,0 +1,122 @@
+package gnomock
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+// deadlineGrace is the time left between the start timeout of a container and
+// the deadline of the test, so that the test can report the failure before it
+// is killed.
+const deadlineGrace = time.Second * 5
+
+// StartT creates a container using the provided Preset, similar to Start, and
+// ties it to the provided test. The test fails immediately if the container
+// can't start, and the error includes container logs collected so far. The
+// container is stopped automatically when the test and all its subtests
+// complete. Container logs are reported using t.Log only if the test fails.
+//
+// If the test has a deadline, the start timeout is reduced to complete before
+// the deadline.
+func StartT(t testing.TB, p Preset, opts ...Option) *Container {
+	t.Helper()
+
+	logs := &syncBuffer{}
+	opts = append(opts, withTest(t, logs))
+
+	c, err := Start(p, opts...)
+	if err != nil {
+		t.Fatalf("can't start %s container: %v\nports: %v\nlogs:\n%s", p.Image(), err, p.Ports(), logs)
+		return nil
+	}
+
+	registerCleanup(t, c, logs)
+
+	return c
+}
+
+// StartCustomT creates a container using the provided image and ports,
+// similar to StartCustom, and ties it to the provided test the same way as
+// StartT does.
+func StartCustomT(t testing.TB, image string, ports NamedPorts, opts ...Option) *Container {
+	t.Helper()
+
+	logs := &syncBuffer{}
+	opts = append(opts, withTest(t, logs))
+
+	c, err := StartCustom(image, ports, opts...)
+	if err != nil {
+		t.Fatalf("can't start %s container: %v\nports: %v\nlogs:\n%s", image, err, ports, logs)
+		return nil
+	}
+
+	registerCleanup(t, c, logs)
+
+	return c
+}
+
+// withTest adds the provided buffer to container log writers, and reduces the
+// start timeout according to the deadline of the test, if any.
+func withTest(t testing.TB, logs io.Writer) Option {
+	return func(o *Options) {
+		if o.logWriter == io.Discard {
+			o.logWriter = logs
+		} else {
+			o.logWriter = io.MultiWriter(o.logWriter, logs)
+		}
+
+		dt, ok := t.(interface{ Deadline() (time.Time, bool) })
+		if !ok {
+			return
+		}
+
+		deadline, ok := dt.Deadline()
+		if !ok {
+			return
+		}
+
+		timeout := time.Until(deadline)
+		if timeout > deadlineGrace*2 {
+			timeout -= deadlineGrace
+		}
+
+		if timeout < o.Timeout {
+			o.Timeout = timeout
+		}
+	}
+}
+
+func registerCleanup(t testing.TB, c *Container, logs *syncBuffer) {
+	t.Cleanup(func() {
+		if err := Stop(c); err != nil {
+			t.Errorf("can't stop container %s: %v", c.ID, err)
+		}
+
+		if t.Failed() {
+			t.Logf("container %s logs:\n%s", c.ID, logs)
+		}
+	})
+}
+
+// syncBuffer is a buffer that can be written and read concurrently.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
@@ -0,0 +1,105 @@
+package gnomock_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/orlangure/gnomock"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a testing.TB that records failures and cleanup functions
+// instead of acting on them.
+type recorder struct {
+	testing.TB
+
+	failed   bool
+	messages []string
+	cleanups []func()
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Cleanup(f func()) {
+	r.cleanups = append(r.cleanups, f)
+}
+
+func (r *recorder) Failed() bool {
+	return r.failed
+}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failed = true
+	r.Logf(format, args...)
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+}
+
+func (r *recorder) Logf(format string, args ...any) {
+	r.messages = append(r.messages, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) cleanup() {
+	for i := len(r.cleanups) - 1; i >= 0; i-- {
+		r.cleanups[i]()
+	}
+}
+
+func TestStartT(t *testing.T) {
+	t.Parallel()
+
+	namedPorts := gnomock.DefaultTCP(80)
+
+	t.Run("stops container on cleanup", func(t *testing.T) {
+		rt := newFakeRuntime("ready\n")
+		r := &recorder{TB: t}
+
+		c := gnomock.StartCustomT(r, "example.com/fake", namedPorts, gnomock.WithRuntime(rt))
+		require.NotNil(t, c)
+		require.Len(t, r.cleanups, 1)
+		require.Len(t, rt.containers, 1)
+
+		r.cleanup()
+		require.False(t, r.failed)
+		require.Empty(t, r.messages)
+		require.Empty(t, rt.containers)
+	})
+
+	t.Run("reports logs of failed test", func(t *testing.T) {
+		rt := newFakeRuntime("ready\n")
+		r := &recorder{TB: t}
+
+		c := gnomock.StartCustomT(r, "example.com/fake", namedPorts, gnomock.WithRuntime(rt))
+		require.NotNil(t, c)
+
+		r.failed = true
+		r.cleanup()
+		require.Len(t, r.messages, 1)
+		require.Contains(t, r.messages[0], "ready")
+	})
+
+	t.Run("fails test with container logs", func(t *testing.T) {
+		rt := newFakeRuntime("something went wrong\n")
+		r := &recorder{TB: t}
+
+		c := gnomock.StartCustomT(
+			r, "example.com/fake", namedPorts,
+			gnomock.WithRuntime(rt),
+			gnomock.WithTimeout(time.Second),
+			gnomock.WithHealthCheck(func(context.Context, *gnomock.Container) error {
+				return fmt.Errorf("not ready")
+			}),
+		)
+		require.Nil(t, c)
+		require.True(t, r.failed)
+		require.Empty(t, r.cleanups)
+		require.Len(t, r.messages, 1)
+		require.Contains(t, r.messages[0], "not ready")
+		require.Contains(t, r.messages[0], "something went wrong")
+		require.Empty(t, rt.containers)
+	})
+}
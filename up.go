@@ -0,0 +1,356 @@
+package gnomock
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/orlangure/gnomock/internal/catalog"
+	"sigs.k8s.io/yaml"
+)
+
+// envFile describes a test environment: a group of containers that may
+// depend on each other, and networks they are attached to.
+type envFile struct {
+	// Networks are the names of networks to create. Containers refer to
+	// them by these names in their options.
+	Networks []string `json:"networks"`
+
+	// Containers are the containers to start by their names.
+	Containers map[string]*envContainer `json:"containers"`
+}
+
+// envContainer describes a single container in an environment file. It is
+// either a preset registered under the provided name, configured the same way
+// as using gnomockd, or a custom container with an image and ports.
+type envContainer struct {
+	Preset string          `json:"preset"`
+	Config json.RawMessage `json:"config"`
+
+	Image string     `json:"image"`
+	Ports NamedPorts `json:"ports"`
+
+	Options   Options  `json:"options"`
+	DependsOn []string `json:"depends_on"`
+}
+
+// Up starts a test environment described in a YAML or JSON file at the
+// provided path, and returns the started containers by their names in the
+// file. For example:
+//
+//	networks:
+//	  - backend
+//	containers:
+//	  db:
+//	    preset: postgres
+//	    config:
+//	      db: app
+//	    options:
+//	      networks:
+//	        backend: [db]
+//	  app:
+//	    image: docker.io/example/app:latest
+//	    ports:
+//	      web: {protocol: tcp, port: 8080}
+//	    depends_on: [db]
+//	    options:
+//	      networks:
+//	        backend: [app]
+//
+// Presets are found by the same names as used by gnomockd, and must be
+// imported by the calling code to become available. Preset configuration and
+// container options use the same format as gnomockd requests.
+//
+// Containers that don't depend on each other start in parallel. Containers
+// with dependencies start after them, and receive the internal addresses of
+// dependencies attached to a network as environment variables named after the
+// dependency and the port, for example DB_DEFAULT_ADDR. Networks listed in the
+// file are created for this environment only, even if no container is attached
+// to them. Use Down to stop the containers and remove the networks.
+func Up(ctx context.Context, path string) (map[string]*Container, error) {
+	bs, err := os.ReadFile(path) // nolint:gosec
+	if err != nil {
+		return nil, fmt.Errorf("can't read environment file: %w", err)
+	}
+
+	env, err := parseEnvFile(bs)
+	if err != nil {
+		return nil, err
+	}
+
+	order, err := env.order()
+	if err != nil {
+		return nil, err
+	}
+
+	networks := make(map[string]*Network, len(env.Networks))
+
+	for _, name := range env.Networks {
+		n, err := NewNetwork(WithContext(ctx))
+		if err != nil {
+			_ = removeNetworks(networks)
+			return nil, err
+		}
+
+		networks[name] = n
+	}
+
+	containers, err := env.start(ctx, order, networks)
+	if err != nil {
+		_ = removeNetworks(networks)
+		return nil, err
+	}
+
+	// every container of the environment keeps all of its networks, including
+	// the ones no container is attached to, so that Down removes them all
+	envNetworks := make([]*Network, 0, len(networks))
+	for _, name := range sortedKeys(networks) {
+		envNetworks = append(envNetworks, networks[name])
+	}
+
+	if len(containers) == 0 {
+		return containers, RemoveNetwork(envNetworks...)
+	}
+
+	for _, c := range containers {
+		c.envNetworks = envNetworks
+	}
+
+	return containers, nil
+}
+
+// Down stops the containers started using Up, and removes the networks
+// created for them.
+func Down(containers map[string]*Container) error {
+	cs := make([]*Container, 0, len(containers))
+	for _, name := range sortedKeys(containers) {
+		cs = append(cs, containers[name])
+	}
+
+	if err := Stop(cs...); err != nil {
+		return err
+	}
+
+	var networks []*Network
+
+	for _, c := range cs {
+		if c == nil {
+			continue
+		}
+
+		for _, n := range c.envNetworks {
+			if !slices.Contains(networks, n) {
+				networks = append(networks, n)
+			}
+		}
+	}
+
+	return RemoveNetwork(networks...)
+}
+
+func parseEnvFile(bs []byte) (*envFile, error) {
+	data, err := yaml.YAMLToJSON(bs)
+	if err != nil {
+		return nil, fmt.Errorf("can't parse environment file: %w", err)
+	}
+
+	env := &envFile{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(env); err != nil {
+		return nil, fmt.Errorf("can't parse environment file: %w", err)
+	}
+
+	for name, c := range env.Containers {
+		if c == nil || (c.Preset == "") == (c.Image == "") {
+			return nil, fmt.Errorf("container %s must have either preset or image", name)
+		}
+	}
+
+	return env, nil
+}
+
+// order returns the names of the containers in an order where every
+// container follows its dependencies.
+func (e *envFile) order() ([]string, error) {
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	state := make(map[string]int, len(e.Containers))
+	order := make([]string, 0, len(e.Containers))
+
+	var visit func(name string) error
+
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("circular dependency of container %s", name)
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+
+		for _, dep := range e.Containers[name].DependsOn {
+			if _, ok := e.Containers[dep]; !ok {
+				return fmt.Errorf("container %s depends on unknown container %s", name, dep)
+			}
+
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		state[name] = visited
+		order = append(order, name)
+
+		return nil
+	}
+
+	for _, name := range sortedKeys(e.Containers) {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// start starts all the containers in the provided order, and attaches them
+// to the provided networks.
+func (e *envFile) start(
+	ctx context.Context,
+	order []string,
+	networks map[string]*Network,
+) (map[string]*Container, error) {
+	b := InParallel()
+	indexes := make(map[string]int, len(order))
+
+	for i, name := range order {
+		c := e.Containers[name]
+
+		p, err := c.preset(name)
+		if err != nil {
+			return nil, err
+		}
+
+		indexes[name] = i
+		opts := c.options(ctx, networks)
+
+		if len(c.DependsOn) == 0 {
+			b.Start(p, opts...)
+			continue
+		}
+
+		deps := make([]int, 0, len(c.DependsOn))
+		for _, dep := range c.DependsOn {
+			deps = append(deps, indexes[dep])
+		}
+
+		b.StartAfter(p, deps, func([]*Container) []Option { return opts })
+	}
+
+	started, err := b.Go()
+	if err != nil {
+		return nil, err
+	}
+
+	containers := make(map[string]*Container, len(order))
+	for i, name := range order {
+		containers[name] = started[i]
+	}
+
+	return containers, nil
+}
+
+// preset returns a preset to start this container with.
+func (c *envContainer) preset(name string) (Preset, error) {
+	if c.Image != "" {
+		return &envPreset{
+			Preset: &customPreset{image: buildImage(c.Image), ports: c.Ports},
+			name:   name,
+		}, nil
+	}
+
+	f, ok := catalog.Get(c.Preset)
+	if !ok {
+		return nil, fmt.Errorf("preset %s of container %s not found", c.Preset, name)
+	}
+
+	p, ok := f().(Preset)
+	if !ok {
+		return nil, fmt.Errorf("preset %s of container %s not found", c.Preset, name)
+	}
+
+	if len(c.Config) > 0 {
+		if err := json.Unmarshal(c.Config, p); err != nil {
+			return nil, fmt.Errorf("invalid config of container %s: %w", name, err)
+		}
+	}
+
+	return &envPreset{Preset: p, name: name}, nil
+}
+
+// options returns the options of this container. Networks created for the
+// environment are referred to by their actual names.
+func (c *envContainer) options(ctx context.Context, networks map[string]*Network) []Option {
+	options := c.Options
+
+	if len(options.Networks) > 0 {
+		options.Networks = make(map[string][]string, len(c.Options.Networks))
+
+		for name, aliases := range c.Options.Networks {
+			if n, ok := networks[name]; ok {
+				name = n.Name
+			}
+
+			options.Networks[name] = aliases
+		}
+	}
+
+	opts := []Option{WithOptions(&options), WithContext(ctx)}
+
+	// WithOptions doesn't override commands, since presets set their own,
+	// but custom containers may need them
+	if c.Image != "" {
+		opts = append(opts, func(o *Options) {
+			o.Cmd, o.Entrypoint = options.Cmd, options.Entrypoint
+		})
+	}
+
+	return opts
+}
+
+func removeNetworks(networks map[string]*Network) error {
+	ns := make([]*Network, 0, len(networks))
+	for _, name := range sortedKeys(networks) {
+		ns = append(ns, networks[name])
+	}
+
+	return RemoveNetwork(ns...)
+}
+
+// envPreset is a preset defined in an environment file. It is named after
+// the container in that file.
+type envPreset struct {
+	Preset
+
+	name string
+}
+
+// customPreset is a custom container defined in an environment file.
+type customPreset struct {
+	image string
+	ports NamedPorts
+}
+
+func (p *customPreset) Image() string     { return p.image }
+func (p *customPreset) Ports() NamedPorts { return p.ports }
+func (p *customPreset) Options() []Option { return nil }
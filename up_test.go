@@ -0,0 +1,59 @@
+package gnomock_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/orlangure/gnomock"
+	"github.com/orlangure/gnomock/internal/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+const testEnvFile = `
+networks: [backend, unused]
+containers:
+  web:
+    image: docker.io/orlangure/gnomock-test-image
+    ports:
+      web80: {protocol: tcp, port: 80}
+      web8080: {protocol: tcp, port: 8080}
+    options:
+      networks:
+        backend: [web]
+  client:
+    image: docker.io/library/busybox:1.35.0
+    ports:
+      default: {protocol: tcp, port: 80}
+    depends_on: [web]
+    options:
+      cmd: [sh, -c, "wget -q -O - http://$WEB_WEB80_ADDR && sleep 60"]
+      networks:
+        backend: []
+`
+
+func TestUp(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "env.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(testEnvFile), 0o600))
+
+	containers, err := gnomock.Up(context.Background(), path)
+	require.NoError(t, err)
+	require.Len(t, containers, 2)
+
+	web := containers["web"]
+	require.NoError(t, testutil.Healthcheck(context.Background(), web))
+	require.Equal(t, "web:80", web.InternalAddress("web80"))
+	require.NotEmpty(t, containers["client"].InternalAddress(gnomock.DefaultPort))
+
+	stdout, _, code, err := containers["client"].Exec(
+		context.Background(), []string{"sh", "-c", "echo $WEB_WEB80_ADDR"},
+	)
+	require.NoError(t, err)
+	require.Equal(t, 0, code)
+	require.Equal(t, "web:80\n", string(stdout))
+
+	require.NoError(t, gnomock.Down(containers))
+}